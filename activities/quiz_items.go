@@ -0,0 +1,82 @@
+package activities
+
+import (
+	"fmt"
+	"log"
+	"path/filepath"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"sort"
+)
+
+// ImportQuizItems один раз переносит встроенные цитаты и кадры клипов в таблицу quiz_items.
+// Если в таблице уже есть вопросы, ничего не делает
+func ImportQuizItems(rep *database.PostgresRepository) error {
+	count, err := rep.CountQuizItems()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	items, err := builtinQuizItems()
+	if err != nil {
+		return err
+	}
+	// Стабильный порядок ID, чтобы список вопросов было удобно читать
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		if items[i].SongName != items[j].SongName {
+			return items[i].SongName < items[j].SongName
+		}
+		return items[i].Quote+items[i].ScreenPath < items[j].Quote+items[j].ScreenPath
+	})
+
+	if err := rep.AddQuizItems(items); err != nil {
+		return err
+	}
+	log.Printf("Imported %d quiz items from built-in lists", len(items))
+	return nil
+}
+
+// builtinQuizItems собирает вопросы из встроенных цитат и кадров, которые реально лежат в папках клипов
+func builtinQuizItems() ([]database.QuizItem, error) {
+	var items []database.QuizItem
+	for quote, song := range textcases.SongQuotes {
+		items = append(items, database.QuizItem{Kind: database.QuizItemQuote, Quote: quote, SongName: song, Difficulty: 1, Enabled: true})
+	}
+	for song, dir := range textcases.GetClipScreensDirs() {
+		screens, err := filepath.Glob(fmt.Sprintf("images/clips/%s/*.jpg", dir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list screens of clip %s: %w", dir, err)
+		}
+		if len(screens) == 0 {
+			log.Printf("builtinQuizItems: no screens found for clip %q in images/clips/%s", song, dir)
+		}
+		for _, screen := range screens {
+			items = append(items, database.QuizItem{Kind: database.QuizItemScreen, ScreenPath: screen, SongName: song, Difficulty: 1, Enabled: true})
+		}
+	}
+	return items, nil
+}
+
+// pickQuiz выбирает включенный вопрос квиза нужного вида с учетом ротации (см. pickRotatedItem).
+// accept отбирает подходящие вопросы, nil - подходят все. Если подходящих вопросов в базе нет,
+// цитаты и кадры берутся из встроенных списков. false - вопросов этого вида нет совсем
+func pickQuiz(rep *database.PostgresRepository, itemKind string, rotation QuizRotation, accept func(database.QuizItem) bool) (QuoteQuiz, bool) {
+	item := pickRotatedItem(rep, itemKind, rotation, accept)
+	if item == nil {
+		return QuoteQuiz{}, false
+	}
+	return QuoteQuiz{
+		Quote:      item.Quote,
+		SongName:   item.SongName,
+		Answer:     item.SongName,
+		ScreenPath: item.ScreenPath,
+		FileID:     item.FileID,
+		ItemID:     item.ID,
+	}, true
+}
@@ -0,0 +1,86 @@
+package activities
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"strconv"
+	"time"
+)
+
+// Очки за победу в квизе
+const quizWinPoints = 10
+
+// Бонус за серию побед: столько очков за каждую победу подряд перед текущей, но не больше quizStreakMax
+const (
+	quizStreakStep = 2
+	quizStreakMax  = 10
+)
+
+// SpeedBonus возвращает бонус за скорость ответа: чем быстрее после начала квиза, тем больше
+func SpeedBonus(delay time.Duration) int {
+	switch {
+	case delay <= 5*time.Minute:
+		return 5
+	case delay <= 15*time.Minute:
+		return 3
+	case delay <= 30*time.Minute:
+		return 1
+	}
+	return 0
+}
+
+// AwardQuizWin начисляет победителю квиза очки за победу, скорость и серию побед. Победитель уже должен быть
+// записан в квиз, чтобы серия посчиталась вместе с ним. Возвращает сумму начисленных очков и их расшифровку
+func AwardQuizWin(rep *database.PostgresRepository, quizID uint, userID int64, speedBonus int) (int, string) {
+	streak, err := rep.GetQuizWinStreak(userID)
+	if err != nil {
+		log.Printf("Failed to get quiz win streak: %v", err)
+	}
+	streakBonus := 0
+	if streak > 1 {
+		streakBonus = min((streak-1)*quizStreakStep, quizStreakMax)
+	}
+
+	total := 0
+	details := ""
+	for _, award := range []struct {
+		reason string
+		title  string
+		points int
+	}{
+		{"win", "победа", quizWinPoints},
+		{"speed", "скорость", speedBonus},
+		{"streak", fmt.Sprintf("серия из %d побед", streak), streakBonus},
+	} {
+		if award.points == 0 {
+			continue
+		}
+		point := &database.QuizPoint{QuizID: quizID, UserID: userID, Points: award.points, Reason: award.reason}
+		if err := rep.AddQuizPoints(point); err != nil {
+			log.Printf("Failed to add %s quiz points for user %d: %v", award.reason, userID, err)
+			continue
+		}
+		total += award.points
+		if details != "" {
+			details += ", "
+		}
+		details += fmt.Sprintf("%s %d", award.title, award.points)
+	}
+	return total, details
+}
+
+// QuizPlayerName возвращает имя участника квиза для таблиц очков и итогов без упоминания через @
+func QuizPlayerName(rep *database.PostgresRepository, userID int64) string {
+	user, err := rep.FindUser(userID)
+	if err != nil || user == nil {
+		return strconv.FormatInt(userID, 10)
+	}
+	if user.FirstName != "" {
+		return user.FirstName
+	}
+	if user.Username != "" {
+		return user.Username
+	}
+	return strconv.FormatInt(userID, 10)
+}
@@ -0,0 +1,118 @@
+package activities
+
+import (
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"strconv"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Максимальная длина вопроса опроса в Telegram
+const pollQuestionLimit = 300
+
+// pollQuestion собирает вопрос опроса из цитаты, обрезая ее под лимит Telegram
+func pollQuestion(quote string) string {
+	question := fmt.Sprintf("%s\n\n%s", textcases.QuizPollQuestion, quote)
+	if runes := []rune(question); len(runes) > pollQuestionLimit {
+		question = string(runes[:pollQuestionLimit-1]) + "…"
+	}
+	return question
+}
+
+// pickPollQuiz выбирает цитату для квиза-опроса, стараясь взять такую, что целиком поместится в вопрос
+func pickPollQuiz(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool) {
+	fits := func(item database.QuizItem) bool {
+		return len([]rune(fmt.Sprintf("%s\n\n%s", textcases.QuizPollQuestion, item.Quote))) <= pollQuestionLimit
+	}
+	if quiz, ok := pickQuiz(rep, database.QuizItemQuote, rotation, fits); ok {
+		return quiz, true
+	}
+	// Длинная цитата обрезается в вопросе опроса
+	return pickQuiz(rep, database.QuizItemQuote, rotation, nil)
+}
+
+// sendQuizPoll отправляет квиз-опрос с четырьмя вариантами ответа и запоминает его, чтобы принимать ответы
+func sendQuizPoll(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, todayQuiz QuoteQuiz, quizChatID int64) {
+	options, correct := textcases.GetPollOptions(todayQuiz.SongName)
+	poll := &tele.Poll{
+		Type:          tele.PollQuiz,
+		Question:      pollQuestion(todayQuiz.Quote),
+		CorrectOption: correct,
+		Anonymous:     false, // Иначе Telegram не присылает ответы участников
+	}
+	poll.AddOptions(options...)
+
+	msg, err := bot.Send(tele.ChatID(quizChatID), poll, &tele.SendOptions{ThreadID: 0})
+	if err != nil {
+		log.Printf("Failed to send quiz poll: %v", err)
+		return
+	}
+	quizManager.UpdateTodayQuiz(func(q *QuoteQuiz) {
+		q.PollMessageID = msg.ID
+	})
+	if err := rep.SetQuizPoll(todayQuiz.QuizID, msg.Poll.ID, msg.ID, correct); err != nil {
+		log.Printf("Failed to save quiz poll: %v", err)
+	}
+}
+
+// closeQuizPoll закрывает опрос, объявляет быстрейших правильно ответивших и выдает титул первому из них
+func closeQuizPoll(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, todayQuiz QuoteQuiz, quizChatID int64) {
+	if todayQuiz.PollMessageID != 0 {
+		poll := &tele.StoredMessage{MessageID: strconv.Itoa(todayQuiz.PollMessageID), ChatID: quizChatID}
+		if _, err := bot.StopPoll(poll); err != nil {
+			log.Printf("Failed to stop quiz poll: %v", err)
+		}
+	}
+
+	points, err := rep.GetQuizPoints(todayQuiz.QuizID)
+	if err != nil {
+		log.Printf("Failed to get quiz poll points: %v", err)
+	}
+	if len(points) == 0 {
+		log.Printf("Quiz poll closed without correct answers, song: %s", todayQuiz.SongName)
+		sendSongReveal(rep, bot, quizChatID, todayQuiz.SongName, fmt.Sprintf("Опрос закрыт, никто не ответил правильно! Песня: %s", todayQuiz.SongName))
+		return
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Опрос закрыт! Песня: %s\n\nБыстрее всех ответили правильно:\n", todayQuiz.SongName))
+	for i, point := range points {
+		if i == 10 {
+			sb.WriteString(fmt.Sprintf("...и еще %d\n", len(points)-i))
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s - %d\n", point.Rank, QuizPlayerName(rep, point.UserID), point.Points))
+	}
+
+	winnerID := points[0].UserID
+	if err := rep.SetQuizWinner(todayQuiz.QuizID, winnerID); err != nil {
+		log.Printf("failed to set user %d as a quiz winner %v", winnerID, err)
+	}
+	winnerTitle := textcases.GetRandomTitle()
+	// Победитель получает временную роль с правом предупреждать и титулом до следующего квиза
+	winnerRole := &database.TempRole{
+		UserID:      winnerID,
+		ChatID:      quizChatID,
+		Kind:        database.TempRoleQuizWinner,
+		Permissions: database.PermWarn,
+		Tag:         winnerTitle,
+	}
+	if err := admins.GrantTempRole(bot, quizManager.WinnerChats(), winnerRole, rep); err != nil {
+		log.Printf("failed to grant quiz winner role to user %d: %v", winnerID, err)
+	}
+	sb.WriteString(fmt.Sprintf("\nПоздравляем, %s! Ты ответил быстрее всех и получил титул %s до следующего квиза!", QuizPlayerName(rep, winnerID), winnerTitle))
+	// Скорость в опросе уже учтена очками за место
+	if total, details := AwardQuizWin(rep, todayQuiz.QuizID, winnerID, 0); total > 0 {
+		sb.WriteString(fmt.Sprintf("\nОчки в сезоне: +%d (%s)", total, details))
+	}
+
+	if _, err := bot.Send(tele.ChatID(quizChatID), sb.String(), &tele.SendOptions{ThreadID: 0}); err != nil {
+		log.Printf("Failed to send quiz poll results: %v", err)
+	}
+	sendSongReveal(rep, bot, quizChatID, todayQuiz.SongName, fmt.Sprintf("Песня: %s", todayQuiz.SongName))
+}
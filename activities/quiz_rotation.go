@@ -0,0 +1,206 @@
+package activities
+
+import (
+	"log"
+	"math/rand"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"time"
+)
+
+// QuizRotation задает, как долго не повторять вопросы и песни квиза
+type QuizRotation struct {
+	ItemWindow time.Duration // Сколько не повторять цитату, кадр или ребус
+	SongWindow time.Duration // Сколько не повторять песню, о каком бы вопросе ни шла речь
+}
+
+// quizUsage - когда вопросы, песни и альбомы последний раз были в квизе
+type quizUsage struct {
+	items   map[uint]time.Time
+	quotes  map[string]time.Time
+	screens map[string]time.Time
+	songs   map[string]time.Time
+	albums  map[string]int // Сколько раз альбом был в квизе за ItemWindow
+}
+
+// loadQuizUsage собирает из истории квизов, когда что последний раз загадывалось. Квизы до появления
+// таблицы вопросов сопоставляются с вопросами по тексту цитаты и пути к кадру. История берется только
+// за самое длинное окно ротации: более старые квизы ни на что не влияют, а таблица растет каждый день
+func loadQuizUsage(rep *database.PostgresRepository, rotation QuizRotation, now time.Time) (*quizUsage, error) {
+	history, err := rep.GetQuizHistory(now.Add(-max(rotation.ItemWindow, rotation.SongWindow)))
+	if err != nil {
+		return nil, err
+	}
+	usage := &quizUsage{
+		items:   make(map[uint]time.Time),
+		quotes:  make(map[string]time.Time),
+		screens: make(map[string]time.Time),
+		songs:   make(map[string]time.Time),
+		albums:  make(map[string]int),
+	}
+	remember := func(m map[string]time.Time, key string, date time.Time) {
+		if key != "" && date.After(m[key]) {
+			m[key] = date
+		}
+	}
+	for _, quiz := range history {
+		if quiz.ItemID != 0 && quiz.Date.After(usage.items[quiz.ItemID]) {
+			usage.items[quiz.ItemID] = quiz.Date
+		}
+		remember(usage.quotes, quiz.Quote, quiz.Date)
+		remember(usage.screens, quiz.ScreenPath, quiz.Date)
+		remember(usage.screens, quiz.FileID, quiz.Date)
+		remember(usage.songs, textcases.NormalizeAnswer(quiz.SongName), quiz.Date)
+		if now.Sub(quiz.Date) < rotation.ItemWindow {
+			album, _ := textcases.FindTrackAlbum(quiz.SongName)
+			usage.albums[album]++
+		}
+	}
+	return usage, nil
+}
+
+// itemLastUsed возвращает, когда вопрос последний раз был в квизе (нулевое время - не был за окно ротации)
+func (u *quizUsage) itemLastUsed(item database.QuizItem) time.Time {
+	last := u.items[item.ID]
+	for _, date := range []time.Time{u.quotes[item.Quote], u.screens[item.ScreenPath], u.screens[item.FileID]} {
+		if date.After(last) {
+			last = date
+		}
+	}
+	return last
+}
+
+// songLastUsed возвращает, когда песня последний раз была ответом квиза (нулевое время - не была за окно ротации)
+func (u *quizUsage) songLastUsed(song string) time.Time {
+	return u.songs[textcases.NormalizeAnswer(song)]
+}
+
+// isFresh проверяет, что ни вопрос, ни его песня не загадывались в пределах окон ротации
+func (u *quizUsage) isFresh(item database.QuizItem, rotation QuizRotation, now time.Time) bool {
+	itemUsed := u.itemLastUsed(item)
+	songUsed := u.songLastUsed(item.SongName)
+	return (itemUsed.IsZero() || now.Sub(itemUsed) >= rotation.ItemWindow) &&
+		(songUsed.IsZero() || now.Sub(songUsed) >= rotation.SongWindow)
+}
+
+// pickRotatedItem выбирает вопрос квиза с учетом истории: вопросы и песни не повторяются в пределах окон
+// ротации, из подходящих берется альбом, реже других бывший в квизе, а в нем - давнее всех загаданный вопрос.
+// Если свежих вопросов не осталось, окна ослабляются: сначала по песне, потом по вопросу.
+// Если подходящих вопросов в базе нет, выбирается из встроенных списков по тем же правилам. nil - вопросов нет
+func pickRotatedItem(rep *database.PostgresRepository, kind string, rotation QuizRotation, accept func(database.QuizItem) bool) *database.QuizItem {
+	items, err := rep.GetEnabledQuizItems(kind)
+	if err != nil {
+		log.Printf("Failed to get quiz items of kind %s: %v", kind, err)
+	}
+	if accept != nil {
+		items = filterQuizItems(items, accept)
+	}
+	if len(items) == 0 {
+		log.Printf("No enabled quiz items of kind %s, using built-in lists", kind)
+		builtin, err := builtinQuizItems()
+		if err != nil {
+			log.Printf("Failed to get built-in quiz items: %v", err)
+		}
+		items = filterQuizItems(builtin, func(item database.QuizItem) bool {
+			return item.Kind == kind && (accept == nil || accept(item))
+		})
+	}
+	if len(items) == 0 {
+		return nil
+	}
+	now := time.Now()
+	usage, err := loadQuizUsage(rep, rotation, now)
+	if err != nil {
+		// Без истории выбираем вслепую, но квиз не срываем
+		log.Printf("Failed to load quiz history: %v", err)
+		usage = &quizUsage{albums: make(map[string]int)}
+	}
+
+	candidates := filterQuizItems(items, func(item database.QuizItem) bool {
+		return usage.isFresh(item, rotation, now)
+	})
+	if len(candidates) == 0 {
+		candidates = filterQuizItems(items, func(item database.QuizItem) bool {
+			return usage.isFresh(item, QuizRotation{ItemWindow: rotation.ItemWindow}, now)
+		})
+	}
+	if len(candidates) == 0 {
+		candidates = items
+	}
+
+	// Альбом, который реже других загадывался в последнее время
+	byAlbum := make(map[string][]database.QuizItem)
+	for _, item := range candidates {
+		album, _ := textcases.FindTrackAlbum(item.SongName)
+		byAlbum[album] = append(byAlbum[album], item)
+	}
+	var albums []string
+	for album := range byAlbum {
+		if len(albums) == 0 || usage.albums[album] < usage.albums[albums[0]] {
+			albums = []string{album}
+		} else if usage.albums[album] == usage.albums[albums[0]] {
+			albums = append(albums, album)
+		}
+	}
+	albumItems := byAlbum[albums[rand.Intn(len(albums))]]
+
+	// В альбоме - вопрос, который давнее всех загадывался, из равных - случайный
+	var oldest []database.QuizItem
+	for _, item := range albumItems {
+		if len(oldest) == 0 || usage.itemLastUsed(item).Before(usage.itemLastUsed(oldest[0])) {
+			oldest = []database.QuizItem{item}
+		} else if usage.itemLastUsed(item).Equal(usage.itemLastUsed(oldest[0])) {
+			oldest = append(oldest, item)
+		}
+	}
+	item := oldest[rand.Intn(len(oldest))]
+	return &item
+}
+
+func filterQuizItems(items []database.QuizItem, keep func(database.QuizItem) bool) []database.QuizItem {
+	var filtered []database.QuizItem
+	for _, item := range items {
+		if keep(item) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// QuizFreshness - сколько вопросов одного вида еще можно загадать, не нарушая ротацию
+type QuizFreshness struct {
+	Kind    string
+	Enabled int            // Включенных вопросов
+	Fresh   int            // Вопросов, которые можно загадать сейчас
+	Unused  int            // Вопросов, которые не загадывались за окно ротации
+	Albums  map[string]int // Свежие вопросы по альбомам
+}
+
+// GetQuizFreshness считает запас свежих вопросов по видам вопросов квиза
+func GetQuizFreshness(rep *database.PostgresRepository, rotation QuizRotation) ([]QuizFreshness, error) {
+	now := time.Now()
+	usage, err := loadQuizUsage(rep, rotation, now)
+	if err != nil {
+		return nil, err
+	}
+	var result []QuizFreshness
+	for _, kind := range []string{database.QuizItemQuote, database.QuizItemScreen, database.QuizItemEmoji} {
+		items, err := rep.GetEnabledQuizItems(kind)
+		if err != nil {
+			return nil, err
+		}
+		freshness := QuizFreshness{Kind: kind, Enabled: len(items), Albums: make(map[string]int)}
+		for _, item := range items {
+			if usage.itemLastUsed(item).IsZero() {
+				freshness.Unused++
+			}
+			if usage.isFresh(item, rotation, now) {
+				freshness.Fresh++
+				album, _ := textcases.FindTrackAlbum(item.SongName)
+				freshness.Albums[album]++
+			}
+		}
+		result = append(result, freshness)
+	}
+	return result, nil
+}
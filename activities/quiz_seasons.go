@@ -0,0 +1,104 @@
+package activities
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+var seasonMonths = []string{"январь", "февраль", "март", "апрель", "май", "июнь", "июль", "август", "сентябрь", "октябрь", "ноябрь", "декабрь"}
+
+// SeasonTitle возвращает название сезона для сообщений: "октябрь 2026"
+func SeasonTitle(season string) string {
+	t, err := time.Parse("2006-01", season)
+	if err != nil {
+		return season
+	}
+	return fmt.Sprintf("%s %d", seasonMonths[t.Month()-1], t.Year())
+}
+
+// ManageQuizSeasons подводит итоги прошлого месячного сезона квиза: сохраняет итоговую таблицу
+// и объявляет чемпиона в чате. Итоги подводятся один раз, с 10:00 первого дня нового сезона
+func ManageQuizSeasons(bot *tele.Bot, rep *database.PostgresRepository, m *QuizManager, postGate chan struct{}, postDone chan struct{}) {
+	// Последний сезон, итоги которого уже подведены. Пустой сезон в базе не сохраняется,
+	// поэтому без этого он проверялся бы заново каждый час
+	var closedSeason string
+	for {
+		now := time.Now().In(MoscowTZ)
+		if now.Hour() < 10 || m.IsRunning() {
+			time.Sleep(time.Hour)
+			continue
+		}
+
+		firstDay := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, MoscowTZ)
+		season := database.SeasonOf(firstDay.AddDate(0, 0, -1))
+		if season == closedSeason {
+			time.Sleep(time.Hour)
+			continue
+		}
+		finished, err := rep.HasQuizSeasonResults(season)
+		if err != nil {
+			log.Printf("failed to check quiz season %s: %v", season, err)
+		} else if finished {
+			closedSeason = season
+		} else {
+			message, closed := finishQuizSeason(rep, season)
+			if closed {
+				closedSeason = season
+			}
+			if message != "" {
+				<-postGate
+				if _, err := bot.Send(tele.ChatID(m.QuizChatID), message, &tele.SendOptions{ThreadID: 0}); err != nil {
+					log.Printf("failed to announce quiz season %s: %v", season, err)
+				}
+				postDone <- struct{}{}
+			}
+		}
+		time.Sleep(time.Hour)
+	}
+}
+
+// finishQuizSeason сохраняет итоговую таблицу сезона и возвращает объявление о чемпионе.
+// Пустая строка - в сезоне никто не набрал очков или произошла ошибка. false - итоги не подведены из-за ошибки
+func finishQuizSeason(rep *database.PostgresRepository, season string) (string, bool) {
+	standings, err := rep.GetQuizStandings(season, 0)
+	if err != nil {
+		log.Printf("failed to get standings of quiz season %s: %v", season, err)
+		return "", false
+	}
+	if len(standings) == 0 {
+		log.Printf("Quiz season %s has no players, nothing to archive", season)
+		return "", true
+	}
+
+	results := make([]database.QuizSeasonResult, 0, len(standings))
+	for _, standing := range standings {
+		results = append(results, database.QuizSeasonResult{
+			Season: season,
+			Rank:   standing.Rank,
+			UserID: standing.UserID,
+			Points: standing.Points,
+		})
+	}
+	if err := rep.SaveQuizSeasonResults(results); err != nil {
+		log.Printf("failed to archive quiz season %s: %v", season, err)
+		return "", false
+	}
+	log.Printf("Quiz season %s archived with %d players", season, len(results))
+
+	var sb strings.Builder
+	champion := standings[0]
+	sb.WriteString(fmt.Sprintf("Квиз-сезон %s завершен! Чемпион - %s, очков: %d!\n\nИтоговая таблица:\n", SeasonTitle(season), QuizPlayerName(rep, champion.UserID), champion.Points))
+	for i, standing := range standings {
+		if i == 10 {
+			break
+		}
+		sb.WriteString(fmt.Sprintf("%d. %s - %d\n", standing.Rank, QuizPlayerName(rep, standing.UserID), standing.Points))
+	}
+	sb.WriteString("\nНовый сезон уже начался, очки обнулены. Удачи!")
+	return sb.String(), true
+}
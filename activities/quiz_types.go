@@ -0,0 +1,245 @@
+package activities
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// QuizType описывает вид квиза: как выбрать вопрос, задать его в чате, проверить ответ и раскрыть его
+type QuizType interface {
+	// Kind возвращает вид квиза (database.QuizKind*)
+	Kind() string
+	// Title возвращает название вида для информации о квизе
+	Title() string
+	// Pick выбирает вопрос. false - вопросов этого вида нет
+	Pick(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool)
+	// Ask отправляет вопрос в чат
+	Ask(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64)
+	// Check сравнивает ответ из чата с ожидаемым
+	Check(answer string, quiz QuoteQuiz, aliases []string) textcases.AnswerMatch
+	// Hints возвращает подсказки по порядку
+	Hints(quiz QuoteQuiz) []string
+	// Reveal возвращает текст с правильным ответом
+	Reveal(quiz QuoteQuiz) string
+	// Expire завершает квиз, на который никто не ответил до срока
+	Expire(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64)
+}
+
+// LoadQuizAliases возвращает встроенные и добавленные админами прозвища песни
+func LoadQuizAliases(rep *database.PostgresRepository, song string) []string {
+	aliases := append([]string{}, textcases.DefaultSongAliases(song)...)
+	stored, err := rep.GetSongAliases(textcases.NormalizeAnswer(song))
+	if err != nil {
+		log.Printf("Failed to get aliases of song %q: %v", song, err)
+		return aliases
+	}
+	for _, alias := range stored {
+		aliases = append(aliases, alias.Alias)
+	}
+	return aliases
+}
+
+// Порядок чередования видов квиза. Виды без вопросов пропускаются
+var quizRotation = []string{
+	database.QuizKindQuote,
+	database.QuizKindClip,
+	database.QuizKindPoll,
+	database.QuizKindLyrics,
+	database.QuizKindEmoji,
+}
+
+var quizTypes = map[string]QuizType{
+	database.QuizKindQuote:  quoteType{},
+	database.QuizKindClip:   clipType{},
+	database.QuizKindPoll:   pollType{},
+	database.QuizKindLyrics: lyricsType{},
+	database.QuizKindEmoji:  emojiType{},
+}
+
+// GetQuizType возвращает вид квиза по названию. Неизвестный вид считается цитатой
+func GetQuizType(kind string) QuizType {
+	if quizType, ok := quizTypes[kind]; ok {
+		return quizType
+	}
+	return quoteType{}
+}
+
+// nextQuizKind возвращает вид квиза, который идет в чередовании после lastQuizKind
+func nextQuizKind(lastQuizKind string) string {
+	for i, kind := range quizRotation {
+		if kind == lastQuizKind {
+			return quizRotation[(i+1)%len(quizRotation)]
+		}
+	}
+	return quizRotation[1]
+}
+
+// sendQuizText отправляет объявление квиза и вопрос отдельными сообщениями
+func sendQuizText(bot *tele.Bot, chatID int64, announcement string, question string) {
+	_, err := bot.Send(tele.ChatID(chatID), announcement, &tele.SendOptions{ThreadID: 0})
+	if err != nil {
+		log.Printf("Failed to send quiz intro message: %v", err)
+	}
+	time.Sleep(100 * time.Millisecond)
+	_, err = bot.Send(tele.ChatID(chatID), question, &tele.SendOptions{ThreadID: 0})
+	if err != nil {
+		log.Printf("Failed to send quiz question message: %v", err)
+	}
+}
+
+// songType - общее поведение квизов, где нужно угадать название песни
+type songType struct{}
+
+func (songType) Check(answer string, quiz QuoteQuiz, aliases []string) textcases.AnswerMatch {
+	return textcases.MatchQuizAnswer(answer, quiz.Answer, aliases)
+}
+
+func (songType) Hints(quiz QuoteQuiz) []string {
+	return textcases.QuizHints(quiz.SongName)
+}
+
+func (songType) Reveal(quiz QuoteQuiz) string {
+	return fmt.Sprintf("Песня: %s", quiz.SongName)
+}
+
+func (t songType) Expire(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	sendSongReveal(rep, bot, chatID, quiz.SongName, "Время вышло, никто не угадал! "+t.Reveal(quiz))
+}
+
+// quoteType - угадать песню по цитате
+type quoteType struct{ songType }
+
+func (quoteType) Kind() string  { return database.QuizKindQuote }
+func (quoteType) Title() string { return "цитата из песни" }
+
+func (quoteType) Pick(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool) {
+	return pickQuiz(rep, database.QuizItemQuote, rotation, nil)
+}
+
+func (quoteType) Ask(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	sendQuizText(bot, chatID, textcases.QuizAnnouncement, fmt.Sprintf("Сегодняшняя цитата:\n%s", quiz.Quote))
+}
+
+// clipType - угадать песню по кадру из клипа
+type clipType struct{ songType }
+
+func (clipType) Kind() string  { return database.QuizKindClip }
+func (clipType) Title() string { return "кадр из клипа" }
+
+func (clipType) Pick(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool) {
+	return pickQuiz(rep, database.QuizItemScreen, rotation, nil)
+}
+
+func (clipType) Ask(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	screen := &tele.Photo{
+		File:    tele.FromDisk(quiz.ScreenPath),
+		Caption: textcases.QuizClipAnnouncement,
+	}
+	if quiz.FileID != "" {
+		screen.File = tele.File{FileID: quiz.FileID}
+	}
+	_, err := bot.Send(tele.ChatID(chatID), screen, &tele.SendOptions{ThreadID: 0})
+	if err != nil {
+		log.Printf("Failed to send quiz clip intro message: %v", err)
+	}
+}
+
+// emojiType - угадать песню по эмодзи-ребусу, придуманному админом
+type emojiType struct{ songType }
+
+func (emojiType) Kind() string  { return database.QuizKindEmoji }
+func (emojiType) Title() string { return "эмодзи-ребус" }
+
+func (emojiType) Pick(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool) {
+	return pickQuiz(rep, database.QuizItemEmoji, rotation, nil)
+}
+
+func (emojiType) Ask(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	sendQuizText(bot, chatID, textcases.QuizEmojiAnnouncement, quiz.Quote)
+}
+
+// pollType - опрос-викторина Telegram с вариантами ответа. Ответы принимаются кнопками опроса
+type pollType struct{}
+
+func (pollType) Kind() string  { return database.QuizKindPoll }
+func (pollType) Title() string { return "опрос с вариантами ответа" }
+
+func (pollType) Pick(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool) {
+	return pickPollQuiz(rep, rotation)
+}
+
+func (pollType) Ask(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	sendQuizPoll(rep, bot, quizManager, quiz, chatID)
+}
+
+// В квизе-опросе отвечают кнопками опроса, а не сообщениями
+func (pollType) Check(answer string, quiz QuoteQuiz, aliases []string) textcases.AnswerMatch {
+	return textcases.AnswerWrong
+}
+
+// В опросе варианты ответа уже есть, подсказки не нужны
+func (pollType) Hints(quiz QuoteQuiz) []string {
+	return nil
+}
+
+func (pollType) Reveal(quiz QuoteQuiz) string {
+	return fmt.Sprintf("Песня: %s", quiz.SongName)
+}
+
+func (pollType) Expire(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	closeQuizPoll(rep, bot, quizManager, quiz, chatID)
+}
+
+// lyricsType - продолжить строчку: бот показывает начало цитаты, ответ - пропущенные слова
+type lyricsType struct{}
+
+func (lyricsType) Kind() string  { return database.QuizKindLyrics }
+func (lyricsType) Title() string { return "продолжи строчку" }
+
+func (lyricsType) Pick(rep *database.PostgresRepository, rotation QuizRotation) (QuoteQuiz, bool) {
+	splittable := func(item database.QuizItem) bool {
+		_, _, ok := textcases.SplitLyrics(item.Quote)
+		return ok
+	}
+	quiz, ok := pickQuiz(rep, database.QuizItemQuote, rotation, splittable)
+	if !ok {
+		return QuoteQuiz{}, false
+	}
+	_, quiz.Answer, _ = textcases.SplitLyrics(quiz.Quote)
+	return quiz, true
+}
+
+func (lyricsType) Ask(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	shown, _, _ := textcases.SplitLyrics(quiz.Quote)
+	sendQuizText(bot, chatID, textcases.QuizLyricsAnnouncement, fmt.Sprintf("%s ...", shown))
+}
+
+func (lyricsType) Check(answer string, quiz QuoteQuiz, aliases []string) textcases.AnswerMatch {
+	return textcases.MatchQuizAnswer(answer, quiz.Answer, nil)
+}
+
+func (lyricsType) Hints(quiz QuoteQuiz) []string {
+	first := "?"
+	if runes := []rune(quiz.Answer); len(runes) > 0 {
+		first = strings.ToUpper(string(runes[0]))
+	}
+	return []string{
+		fmt.Sprintf("Подсказка: это песня \"%s\"", quiz.SongName),
+		fmt.Sprintf("Подсказка: пропущенные слова начинаются на \"%s\"", first),
+		fmt.Sprintf("Подсказка: пропущено слов - %d", len(strings.Fields(quiz.Answer))),
+	}
+}
+
+func (lyricsType) Reveal(quiz QuoteQuiz) string {
+	return fmt.Sprintf("Песня: %s\n\n%s", quiz.SongName, quiz.Quote)
+}
+
+func (t lyricsType) Expire(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, quiz QuoteQuiz, chatID int64) {
+	sendSongReveal(rep, bot, chatID, quiz.SongName, "Время вышло, никто не угадал! "+t.Reveal(quiz))
+}
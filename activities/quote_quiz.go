@@ -6,7 +6,6 @@ import (
 	"math/rand"
 	"saxbot/admins"
 	"saxbot/database"
-	textcases "saxbot/text_cases"
 	"sync"
 	"time"
 
@@ -14,11 +13,16 @@ import (
 )
 
 type QuoteQuiz struct {
-	Quote      string
-	SongName   string
-	QuizTime   time.Time
-	IsClip     bool
-	ScreenPath string
+	Quote         string
+	SongName      string
+	QuizTime      time.Time
+	ScreenPath    string
+	FileID        string // Кадр, загруженный в Telegram (приоритетнее ScreenPath)
+	ItemID        uint   // Вопрос из quiz_items (0 - из встроенных списков)
+	Kind          string // Вид квиза: database.QuizKind*, см. GetQuizType
+	Answer        string // Ожидаемый ответ: название песни или пропущенные слова
+	QuizID        uint   // Запись квиза в таблице quizzes
+	PollMessageID int    // Сообщение с опросом квиза-опроса
 }
 
 type QuizManager struct {
@@ -27,15 +31,23 @@ type QuizManager struct {
 	QuizRunning    bool
 	QuizAlreadyWas bool
 	WinnerID       int64
-	IsLastQuizClip bool
+	LastQuizKind   string // Вид последнего проведенного квиза для чередования
 	QuizChatID     int64
+	AllowedChats   []int64         // Чаты, в которых победитель получает роль и титул
+	HintAfter      []time.Duration // Через сколько после начала квиза давать подсказки
+	Deadline       time.Duration   // Через сколько без ответа раскрыть песню (0 - ждать до победителя)
+	PollDuration   time.Duration   // Сколько открыт опрос квиза-опроса
+	Rotation       QuizRotation    // Как долго не повторять вопросы и песни
+	startedAt      time.Time
+	hintsSent      int
+	aliases        []string // Прозвища песни идущего квиза
 }
 
 // Thread-safe helpers
-func (qm *QuizManager) GetState() (todayQuiz QuoteQuiz, quizRunning, quizAlreadyWas bool, winnerID int64, isLastQuizClip bool, quizChatID int64) {
+func (qm *QuizManager) GetState() (todayQuiz QuoteQuiz, quizRunning, quizAlreadyWas bool, winnerID int64, lastQuizKind string, quizChatID int64) {
 	qm.mu.RLock()
 	defer qm.mu.RUnlock()
-	return qm.TodayQuiz, qm.QuizRunning, qm.QuizAlreadyWas, qm.WinnerID, qm.IsLastQuizClip, qm.QuizChatID
+	return qm.TodayQuiz, qm.QuizRunning, qm.QuizAlreadyWas, qm.WinnerID, qm.LastQuizKind, qm.QuizChatID
 }
 
 func (qm *QuizManager) SetTodayQuiz(quiz QuoteQuiz) {
@@ -56,9 +68,9 @@ func (qm *QuizManager) SetQuizAlreadyWas(done bool) {
 	qm.mu.Unlock()
 }
 
-func (qm *QuizManager) SetIsLastQuizClip(isClip bool) {
+func (qm *QuizManager) SetLastQuizKind(kind string) {
 	qm.mu.Lock()
-	qm.IsLastQuizClip = isClip
+	qm.LastQuizKind = kind
 	qm.mu.Unlock()
 }
 
@@ -82,6 +94,82 @@ func (qm *QuizManager) ResetForNewDay() {
 	qm.mu.Unlock()
 }
 
+// StartQuiz помечает квиз начатым, сбрасывает счетчик подсказок и запоминает прозвища песни,
+// чтобы не читать их из базы на каждое сообщение в чате
+func (qm *QuizManager) StartQuiz(at time.Time, aliases []string) {
+	qm.mu.Lock()
+	qm.QuizRunning = true
+	qm.startedAt = at
+	qm.aliases = aliases
+	qm.hintsSent = 0
+	qm.mu.Unlock()
+}
+
+// FinishQuiz завершает идущий квиз. Возвращает false, если квиз уже завершен победителем или по сроку,
+// чтобы ответ и раскрытие песни не сработали одновременно
+func (qm *QuizManager) FinishQuiz() bool {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	if !qm.QuizRunning {
+		return false
+	}
+	qm.QuizRunning = false
+	qm.QuizAlreadyWas = true
+	return true
+}
+
+// NextHint возвращает номер подсказки, которую пора дать, и отмечает ее выданной. Второе значение false,
+// если квиз не идет или время следующей подсказки еще не пришло
+func (qm *QuizManager) NextHint(now time.Time) (int, bool) {
+	qm.mu.Lock()
+	defer qm.mu.Unlock()
+	if !qm.QuizRunning || qm.hintsSent >= len(qm.HintAfter) || now.Sub(qm.startedAt) < qm.HintAfter[qm.hintsSent] {
+		return 0, false
+	}
+	qm.hintsSent++
+	return qm.hintsSent - 1, true
+}
+
+// WinnerChats возвращает чаты, в которых победителю квиза выдается роль. Если список не задан - только чат квиза
+func (qm *QuizManager) WinnerChats() []int64 {
+	if len(qm.AllowedChats) > 0 {
+		return qm.AllowedChats
+	}
+	return []int64{qm.QuizChatID}
+}
+
+// Aliases возвращает прозвища песни идущего квиза
+func (qm *QuizManager) Aliases() []string {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.aliases
+}
+
+// SetAliases обновляет прозвища песни идущего квиза, например после /alias
+func (qm *QuizManager) SetAliases(aliases []string) {
+	qm.mu.Lock()
+	qm.aliases = aliases
+	qm.mu.Unlock()
+}
+
+// StartedAt возвращает время начала идущего квиза
+func (qm *QuizManager) StartedAt() time.Time {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	return qm.startedAt
+}
+
+// DeadlinePassed проверяет, истек ли срок ответа на идущий квиз. Опрос закрывается через PollDuration
+func (qm *QuizManager) DeadlinePassed(now time.Time) bool {
+	qm.mu.RLock()
+	defer qm.mu.RUnlock()
+	deadline := qm.Deadline
+	if qm.TodayQuiz.Kind == database.QuizKindPoll {
+		deadline = qm.PollDuration
+	}
+	return qm.QuizRunning && deadline > 0 && now.Sub(qm.startedAt) >= deadline
+}
+
 func (qm *QuizManager) IsRunning() bool {
 	qm.mu.RLock()
 	defer qm.mu.RUnlock()
@@ -109,56 +197,50 @@ func estimateQuizTime() time.Time {
 	return quizTime
 }
 
-func getTodayQuiz(isLastQuizClip bool) QuoteQuiz {
-	if isLastQuizClip {
-		quote, songName := textcases.GetRandomQuote()
-		quizTime := estimateQuizTime()
-
-		return QuoteQuiz{
-			Quote:    quote,
-			SongName: songName,
-			QuizTime: quizTime,
+func getTodayQuiz(db *database.PostgresRepository, lastQuizKind string, rotation QuizRotation) QuoteQuiz {
+	// Виды квиза чередуются, вид без вопросов пропускается
+	kind := lastQuizKind
+	for range quizRotation {
+		kind = nextQuizKind(kind)
+		if quiz, ok := GetQuizType(kind).Pick(db, rotation); ok {
+			quiz.Kind = kind
+			quiz.QuizTime = estimateQuizTime()
+			return quiz
 		}
+		log.Printf("No questions for quiz kind %s, skipping", kind)
 	}
-	return getClipQuiz()
+	// Вопросов нет ни в одном виде: пробуем цитату еще раз, иначе данные квиза подберутся перед стартом
+	quiz, _ := pickQuiz(db, database.QuizItemQuote, rotation, nil)
+	quiz.Kind = database.QuizKindQuote
+	quiz.QuizTime = estimateQuizTime()
+	return quiz
 }
 
-func getNewQuiz(db *database.PostgresRepository, isLastQuizClip bool) (todayQuiz QuoteQuiz) {
-	todayQuiz = getTodayQuiz(isLastQuizClip)
+func getNewQuiz(db *database.PostgresRepository, lastQuizKind string, rotation QuizRotation) (todayQuiz QuoteQuiz) {
+	todayQuiz = getTodayQuiz(db, lastQuizKind, rotation)
 
-	log.Printf("Generated quiz: Quote='%s', SongName='%s', Time=%s, IsClip='%t', ScreenPath='%s'", todayQuiz.Quote, todayQuiz.SongName, todayQuiz.QuizTime.Format("15:04"), todayQuiz.IsClip, todayQuiz.ScreenPath)
+	log.Printf("Generated quiz: Quote='%s', SongName='%s', Time=%s, Kind='%s', ScreenPath='%s'", todayQuiz.Quote, todayQuiz.SongName, todayQuiz.QuizTime.Format("15:04"), todayQuiz.Kind, todayQuiz.ScreenPath)
 
-	if err := db.SaveQuizData(todayQuiz.Quote, todayQuiz.SongName, todayQuiz.ScreenPath, todayQuiz.IsClip, todayQuiz.QuizTime); err != nil {
+	quiz := &database.Quiz{
+		Quote:      todayQuiz.Quote,
+		SongName:   todayQuiz.SongName,
+		QuizTime:   todayQuiz.QuizTime,
+		ScreenPath: todayQuiz.ScreenPath,
+		FileID:     todayQuiz.FileID,
+		ItemID:     todayQuiz.ItemID,
+		Kind:       todayQuiz.Kind,
+		Answer:     todayQuiz.Answer,
+	}
+	if err := db.SaveQuizData(quiz); err != nil {
 		log.Printf("Ошибка сохранения данных квиза: %v", err)
 	} else {
-		log.Printf("Установлены и сохранены полные данные квиза на сегодня: Quote='%s', SongName='%s', Time=%s, IsClip='%t', ScreenPath='%s'",
-			todayQuiz.Quote, todayQuiz.SongName, todayQuiz.QuizTime.Format("15:04"), todayQuiz.IsClip, todayQuiz.ScreenPath)
+		todayQuiz.QuizID = quiz.ID
+		log.Printf("Установлены и сохранены полные данные квиза на сегодня: Quote='%s', SongName='%s', Time=%s, Kind='%s', ScreenPath='%s'",
+			todayQuiz.Quote, todayQuiz.SongName, todayQuiz.QuizTime.Format("15:04"), todayQuiz.Kind, todayQuiz.ScreenPath)
 	}
 	return todayQuiz
 }
 
-func getClipQuiz() QuoteQuiz {
-	clipName := textcases.GetRandomClip()
-	quizTime := estimateQuizTime()
-	basePath := "images/clips/%s/%d.jpg"
-	randImage := rand.Intn(7) + 1
-	for key, value := range clipName {
-		screenPath := fmt.Sprintf(basePath, value, randImage)
-		return QuoteQuiz{
-			SongName:   key,
-			ScreenPath: screenPath,
-			QuizTime:   quizTime,
-			IsClip:     true,
-		}
-	}
-	return QuoteQuiz{
-		SongName:   "",
-		ScreenPath: "",
-		QuizTime:   quizTime,
-		IsClip:     true,
-	}
-}
-
 func ManageQuiz(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, postGate chan struct{}, postDone chan struct{}) {
 	moscowTZ := time.FixedZone("Moscow", 3*60*60)
 
@@ -173,18 +255,23 @@ func ManageQuiz(rep *database.PostgresRepository, bot *tele.Bot, quizManager *Qu
 
 	if lastQuiz == nil {
 		log.Println("Завершённых квизов не найдено — вероятно новая или очищенная БД")
-		quizManager.SetIsLastQuizClip(false)
+		quizManager.SetLastQuizKind("")
 	} else {
 		lastQuizDate = lastQuiz.Date.In(moscowTZ)
-		quizManager.SetIsLastQuizClip(lastQuiz.IsClip)
+		quizManager.SetLastQuizKind(lastQuiz.Kind)
 		if !today.After(lastQuizDate) {
 			quizManager.SetQuizAlreadyWas(true)
 			quizManager.SetTodayQuiz(QuoteQuiz{
-				Quote:      lastQuiz.Quote,
-				SongName:   lastQuiz.SongName,
-				QuizTime:   lastQuiz.QuizTime,
-				IsClip:     lastQuiz.IsClip,
-				ScreenPath: lastQuiz.ScreenPath,
+				Quote:         lastQuiz.Quote,
+				SongName:      lastQuiz.SongName,
+				QuizTime:      lastQuiz.QuizTime,
+				ScreenPath:    lastQuiz.ScreenPath,
+				FileID:        lastQuiz.FileID,
+				ItemID:        lastQuiz.ItemID,
+				Kind:          lastQuiz.Kind,
+				Answer:        lastQuiz.Answer,
+				QuizID:        lastQuiz.ID,
+				PollMessageID: lastQuiz.PollMessageID,
 			})
 			log.Printf("Квиз сегодня уже был проведен")
 		}
@@ -200,21 +287,21 @@ func ManageQuiz(rep *database.PostgresRepository, bot *tele.Bot, quizManager *Qu
 		// Пересчитываем "сегодня" каждый цикл
 		today = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, moscowTZ)
 
-		todayQuiz, quizRunning, quizAlreadyWas, _, isLastQuizClip, _ := quizManager.GetState()
+		todayQuiz, quizRunning, quizAlreadyWas, _, lastQuizKind, _ := quizManager.GetState()
 
 		// Если наступили новые сутки, сбрасываем состояние квиза
 		if !today.Equal(currentDay) {
 			currentDay = today
 			quizManager.ResetForNewDay()
-			// Обновляем isLastQuizClip из базы данных при смене дня
+			// Обновляем вид последнего квиза из базы данных при смене дня
 			lastQuiz, err := rep.GetLastCompletedQuiz()
 			if err == nil && lastQuiz != nil {
-				quizManager.SetIsLastQuizClip(lastQuiz.IsClip)
-				isLastQuizClip = lastQuiz.IsClip
+				quizManager.SetLastQuizKind(lastQuiz.Kind)
+				lastQuizKind = lastQuiz.Kind
 			} else {
-				quizManager.SetIsLastQuizClip(false)
-				isLastQuizClip = false
-				log.Printf("Не удалось загрузить данные последнего квиза, устанавливаем isLastQuizClip в false")
+				quizManager.SetLastQuizKind("")
+				lastQuizKind = ""
+				log.Printf("Не удалось загрузить данные последнего квиза, начинаем чередование сначала")
 			}
 			todayQuiz = QuoteQuiz{}
 			quizRunning = false
@@ -223,7 +310,7 @@ func ManageQuiz(rep *database.PostgresRepository, bot *tele.Bot, quizManager *Qu
 
 		// Если на сегодня нет сгенерированного времени квиза и квиз ещё не проводился — создаём
 		if !quizAlreadyWas && todayQuiz.QuizTime.IsZero() {
-			newQuiz := getNewQuiz(rep, isLastQuizClip)
+			newQuiz := getNewQuiz(rep, lastQuizKind, quizManager.Rotation)
 			quizManager.SetTodayQuiz(newQuiz)
 			todayQuiz = newQuiz
 		}
@@ -232,61 +319,103 @@ func ManageQuiz(rep *database.PostgresRepository, bot *tele.Bot, quizManager *Qu
 		log.Printf("quizAlreadyWas: %v, quizRunning: %v", quizAlreadyWas, quizRunning)
 
 		if now.After(todayQuiz.QuizTime) && !quizAlreadyWas && !quizRunning {
-			// Проверяем и заполняем данные только для квизов-цитат (не клипов)
-			if !todayQuiz.IsClip && (todayQuiz.Quote == "" || todayQuiz.SongName == "") {
-				quote, songName := textcases.GetRandomQuote()
-				quizManager.UpdateTodayQuiz(func(q *QuoteQuiz) {
-					q.Quote = quote
-					q.SongName = songName
-				})
-				todayQuiz.Quote = quote
-				todayQuiz.SongName = songName
-				log.Printf("Установлены данные квиза: Quote='%s', SongName='%s'", quote, songName)
-			} else if todayQuiz.IsClip && (todayQuiz.ScreenPath == "" || todayQuiz.SongName == "") {
-				clipName := textcases.GetRandomClip()
-				for key, value := range clipName {
+			// Если данные квиза потерялись, выбираем вопрос того же вида заново
+			if todayQuiz.SongName == "" || todayQuiz.Answer == "" || (todayQuiz.Kind == database.QuizKindClip && todayQuiz.ScreenPath == "" && todayQuiz.FileID == "") || (todayQuiz.Kind != database.QuizKindClip && todayQuiz.Quote == "") {
+				if picked, ok := GetQuizType(todayQuiz.Kind).Pick(rep, quizManager.Rotation); ok {
 					quizManager.UpdateTodayQuiz(func(q *QuoteQuiz) {
-						q.ScreenPath = value
-						q.SongName = key
+						q.Quote = picked.Quote
+						q.SongName = picked.SongName
+						q.Answer = picked.Answer
+						q.ScreenPath = picked.ScreenPath
+						q.FileID = picked.FileID
+						q.ItemID = picked.ItemID
 					})
-					todayQuiz.ScreenPath = value
-					todayQuiz.SongName = key
+					todayQuiz.Quote = picked.Quote
+					todayQuiz.SongName = picked.SongName
+					todayQuiz.Answer = picked.Answer
+					todayQuiz.ScreenPath = picked.ScreenPath
+					todayQuiz.FileID = picked.FileID
+					todayQuiz.ItemID = picked.ItemID
+					log.Printf("Установлены данные квиза: Quote='%s', ScreenPath='%s', FileID='%s', SongName='%s'", todayQuiz.Quote, todayQuiz.ScreenPath, todayQuiz.FileID, todayQuiz.SongName)
 				}
-				log.Printf("Установлены данные квиза: ScreenPath='%s', SongName='%s'", todayQuiz.ScreenPath, todayQuiz.SongName)
 			}
 
 			<-postGate
 			_, _, _, _, _, quizChatID := quizManager.GetState()
-			admins.RemovePref(bot, &tele.Chat{ID: quizChatID}, rep)
+			// Снимаем роль и титул прошлого победителя; для победителей до появления временных ролей снимаем тег по старинке
+			revoked, err := admins.RevokeTempRolesByKind(bot, quizManager.WinnerChats(), database.TempRoleQuizWinner, rep)
+			if err != nil {
+				log.Printf("Failed to revoke quiz winner role: %v", err)
+			} else if revoked == 0 {
+				admins.RemovePref(bot, &tele.Chat{ID: quizChatID}, rep)
+			}
 
-			quizManager.SetQuizRunning(true)
+			quizManager.StartQuiz(time.Now(), LoadQuizAliases(rep, todayQuiz.SongName))
 			log.Printf("Starting quiz in chat %d", quizChatID)
-			if !todayQuiz.IsClip {
-				_, err = bot.Send(tele.ChatID(quizChatID), textcases.QuizAnnouncement, &tele.SendOptions{ThreadID: 0})
-				if err != nil {
-					log.Printf("Failed to send quiz intro message: %v", err)
-				}
-				time.Sleep(100 * time.Millisecond)
-				quoteMessage := fmt.Sprintf("Сегодняшняя цитата:\n%s", todayQuiz.Quote)
-				_, err = bot.Send(tele.ChatID(quizChatID), quoteMessage, &tele.SendOptions{ThreadID: 0})
-				if err != nil {
-					log.Printf("Failed to send quiz question message: %v", err)
-				}
-			} else {
-				screen := &tele.Photo{
-					File:    tele.FromDisk(todayQuiz.ScreenPath),
-					Caption: textcases.QuizClipAnnouncement,
-				}
-				_, err = bot.Send(tele.ChatID(quizChatID), screen, &tele.SendOptions{ThreadID: 0})
-				if err != nil {
-					log.Printf("Failed to send quiz clip intro message: %v", err)
-				}
-			}
+			GetQuizType(todayQuiz.Kind).Ask(rep, bot, quizManager, todayQuiz, quizChatID)
 			postDone <- struct{}{}
 		}
 
+		// Подсказки и раскрытие песни, если никто не угадал
+		if quizManager.IsRunning() {
+			manageQuizHints(rep, bot, quizManager, now)
+		}
+
 		winner, _ := rep.GetQuizWinnerID()
 		quizManager.SetWinnerID(winner)
 		time.Sleep(1 * time.Minute)
 	}
 }
+
+// manageQuizHints дает очередную подсказку к идущему квизу, а по истечении срока завершает квиз
+// без победителя так, как это принято для его вида
+func manageQuizHints(rep *database.PostgresRepository, bot *tele.Bot, quizManager *QuizManager, now time.Time) {
+	todayQuiz, _, _, _, _, quizChatID := quizManager.GetState()
+	quizType := GetQuizType(todayQuiz.Kind)
+
+	if quizManager.DeadlinePassed(now) {
+		if !quizManager.FinishQuiz() {
+			return
+		}
+		if err := rep.SetQuizAlreadyWas(); err != nil {
+			log.Printf("Failed to mark expired quiz as completed: %v", err)
+		}
+		quizManager.SetLastQuizKind(todayQuiz.Kind)
+		log.Printf("Quiz expired, kind: %s, song: %s", todayQuiz.Kind, todayQuiz.SongName)
+		quizType.Expire(rep, bot, quizManager, todayQuiz, quizChatID)
+		return
+	}
+
+	hints := quizType.Hints(todayQuiz)
+	for {
+		hint, ok := quizManager.NextHint(now)
+		if !ok {
+			return
+		}
+		if hint >= len(hints) {
+			continue
+		}
+		if _, err := bot.Send(tele.ChatID(quizChatID), hints[hint], &tele.SendOptions{ThreadID: 0}); err != nil {
+			log.Printf("Failed to send quiz hint: %v", err)
+		}
+	}
+}
+
+// sendSongReveal присылает в чат трек с ответом квиза, а если трека нет в базе - только текст
+func sendSongReveal(rep *database.PostgresRepository, bot *tele.Bot, chatID int64, song string, caption string) {
+	audio, err := rep.GetAudioByName(song)
+	if err != nil {
+		_, err = bot.Send(tele.ChatID(chatID), caption, &tele.SendOptions{ThreadID: 0})
+	} else {
+		if audio.ClipURL != "" {
+			caption = fmt.Sprintf("%s\n\n<b><a href=\"%s\">Смотреть клип</a></b>", caption, audio.ClipURL)
+		}
+		_, err = bot.Send(tele.ChatID(chatID), &tele.Audio{File: tele.File{FileID: audio.FileID}, Caption: caption}, &tele.SendOptions{
+			ParseMode: tele.ModeHTML,
+			ThreadID:  0,
+		})
+	}
+	if err != nil {
+		log.Printf("Failed to send quiz answer reveal: %v", err)
+	}
+}
@@ -0,0 +1,190 @@
+package admins
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Результат сверки админов бота с администраторами чатов в Telegram
+type AdminSyncResult struct {
+	Usernames  []string          // Юзернеймы админов чатов для вызова командой "админ"
+	Added      []int64           // Админы чатов, добавленные в таблицу admins
+	NotInChats []int64           // Админы из базы, которые не админы ни в одном чате
+	Partial    map[int64][]int64 // Админы, которые админы не во всех чатах (userID -> чаты, где админки нет)
+	Anonymous  []int64           // Анонимные админы, пишущие от имени группы
+	Names      map[int64]string  // Обращения к пользователям для отчета
+}
+
+// HasMismatches сообщает, есть ли расхождения, о которых стоит сообщить главному админу
+func (r AdminSyncResult) HasMismatches() bool {
+	return len(r.Added) > 0 || len(r.NotInChats) > 0 || len(r.Partial) > 0
+}
+
+// Report формирует отчет о расхождениях для главного админа
+func (r AdminSyncResult) Report() string {
+	var sb strings.Builder
+	sb.WriteString("Сверка админов с чатами:\n")
+	if len(r.Added) > 0 {
+		sb.WriteString("\nДобавлены в базу с ролью junior (админы в Telegram):\n")
+		for _, userID := range r.Added {
+			sb.WriteString(fmt.Sprintf("- %s (%d)\n", r.name(userID), userID))
+		}
+	}
+	if len(r.NotInChats) > 0 {
+		sb.WriteString("\nАдмины в базе, но не админы ни в одном чате (снять: /revoke <id>):\n")
+		for _, userID := range r.NotInChats {
+			sb.WriteString(fmt.Sprintf("- %s (%d)\n", r.name(userID), userID))
+		}
+	}
+	if len(r.Partial) > 0 {
+		sb.WriteString("\nАдмины не во всех чатах:\n")
+		ids := make([]int64, 0, len(r.Partial))
+		for userID := range r.Partial {
+			ids = append(ids, userID)
+		}
+		slices.Sort(ids)
+		for _, userID := range ids {
+			chats := make([]string, 0, len(r.Partial[userID]))
+			for _, chatID := range r.Partial[userID] {
+				chats = append(chats, fmt.Sprintf("%d", chatID))
+			}
+			sb.WriteString(fmt.Sprintf("- %s (%d), нет админки в: %s\n", r.name(userID), userID, strings.Join(chats, ", ")))
+		}
+	}
+	if len(r.Anonymous) > 0 {
+		sb.WriteString(fmt.Sprintf("\nАнонимных админов: %d, их сообщения от имени группы считаются админскими\n", len(r.Anonymous)))
+	}
+	return sb.String()
+}
+
+func (r AdminSyncResult) name(userID int64) string {
+	if name, ok := r.Names[userID]; ok && name != "" {
+		return name
+	}
+	return fmt.Sprintf("User %d", userID)
+}
+
+// Сверить админов бота с администраторами всех разрешенных чатов.
+// Админы чатов, которых нет в базе, добавляются с ролью junior. Админов из базы, которых нет в чатах,
+// не удаляем — роль могла быть выдана через /grant, о них только сообщаем. protected - админы из
+// переменных окружения, о них не сообщаем
+func SyncChatAdmins(bot *tele.Bot, chats []int64, protected []int64, db *database.PostgresRepository) (AdminSyncResult, error) {
+	result := AdminSyncResult{
+		Partial: make(map[int64][]int64),
+		Names:   make(map[int64]string),
+	}
+
+	adminChats := make(map[int64][]int64) // userID -> чаты, где он админ
+	users := make(map[int64]*tele.User)
+	var usernames []string
+	checkedChats := make([]int64, 0, len(chats))
+
+	for _, chatID := range chats {
+		members, err := bot.AdminsOf(&tele.Chat{ID: chatID})
+		if err != nil {
+			log.Printf("SyncChatAdmins: failed to get admins of chat %d: %v", chatID, err)
+			continue
+		}
+		checkedChats = append(checkedChats, chatID)
+		for _, member := range members {
+			if member.User == nil || member.User.IsBot {
+				continue
+			}
+			userID := member.User.ID
+			if !slices.Contains(adminChats[userID], chatID) {
+				adminChats[userID] = append(adminChats[userID], chatID)
+			}
+			users[userID] = member.User
+			if member.Anonymous {
+				if !slices.Contains(result.Anonymous, userID) {
+					result.Anonymous = append(result.Anonymous, userID)
+				}
+				continue
+			}
+			if member.User.Username != "" && !slices.Contains(usernames, member.User.Username) {
+				usernames = append(usernames, member.User.Username)
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	if len(checkedChats) == 0 {
+		return result, fmt.Errorf("failed to get admins of any allowed chat")
+	}
+	sort.Strings(usernames)
+	result.Usernames = usernames
+
+	dbAdmins, err := db.GetAllAdminsMap()
+	if err != nil {
+		return result, fmt.Errorf("failed to get admins from database: %w", err)
+	}
+
+	for userID, user := range users {
+		result.Names[userID] = appealOf(user)
+
+		userData, err := db.GetUser(userID)
+		if err != nil {
+			log.Printf("SyncChatAdmins: failed to get user %d: %v", userID, err)
+			continue
+		}
+		if userData.Username != user.Username || userData.FirstName != user.FirstName {
+			db.RecordUsernameChange(userID, userData.Username, userData.FirstName, user.Username, user.FirstName)
+			userData.Username = user.Username
+			userData.FirstName = user.FirstName
+			if err := db.SaveUser(&userData); err != nil {
+				log.Printf("SyncChatAdmins: failed to save user %d: %v", userID, err)
+			}
+		}
+
+		if _, ok := dbAdmins[userID]; !ok {
+			if err := db.SaveAdmin(userData, "junior"); err != nil {
+				log.Printf("SyncChatAdmins: failed to save admin %d: %v", userID, err)
+				continue
+			}
+			result.Added = append(result.Added, userID)
+		}
+
+		if len(adminChats[userID]) < len(checkedChats) && !slices.Contains(protected, userID) {
+			for _, chatID := range checkedChats {
+				if !slices.Contains(adminChats[userID], chatID) {
+					result.Partial[userID] = append(result.Partial[userID], chatID)
+				}
+			}
+		}
+	}
+
+	for userID := range dbAdmins {
+		if _, ok := users[userID]; ok || slices.Contains(protected, userID) {
+			continue
+		}
+		result.NotInChats = append(result.NotInChats, userID)
+		if userData, err := db.GetUser(userID); err == nil {
+			if userData.Username != "" {
+				result.Names[userID] = "@" + userData.Username
+			} else {
+				result.Names[userID] = userData.FirstName
+			}
+		}
+	}
+
+	slices.Sort(result.Added)
+	slices.Sort(result.NotInChats)
+	slices.Sort(result.Anonymous)
+
+	log.Printf("SyncChatAdmins: checked %d chats, %d chat admins, added %d, not in chats %d",
+		len(checkedChats), len(users), len(result.Added), len(result.NotInChats))
+	return result, nil
+}
+
+func appealOf(user *tele.User) string {
+	if user.Username != "" {
+		return "@" + user.Username
+	}
+	return user.FirstName
+}
@@ -22,8 +22,12 @@ func BanUser(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *database
 	}
 
 	existingData.Status = "banned"
-	db.SaveUser(&existingData)
-	bot.Ban(chat, user)
+	if err := db.SaveUser(&existingData); err != nil {
+		log.Printf("BanUser: failed to save data for user %d: %v", user.User.ID, err)
+	}
+	if err := bot.Ban(chat, user); err != nil {
+		log.Printf("BanUser: failed to ban user %d in chat %d: %v", user.User.ID, chat.ID, err)
+	}
 }
 
 // Разбанить юзера
@@ -58,7 +62,9 @@ func MuteUser(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *databas
 	db.SaveUser(&existingData)
 
 	user.Rights = tele.Rights{CanSendMessages: false}
-	bot.Restrict(chat, user)
+	if err := bot.Restrict(chat, user); err != nil {
+		log.Printf("MuteUser: failed to restrict user %d in chat %d: %v", user.User.ID, chat.ID, err)
+	}
 
 	err = db.SaveUserMutedUntil(existingData.UserID, x)
 	if err != nil {
@@ -86,16 +92,8 @@ func MuteUser(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *databas
 			}
 
 			unmuteUser := &tele.ChatMember{
-				User: userCopy,
-				Rights: tele.Rights{
-					CanSendMessages:  true,
-					CanSendMedia:     true,
-					CanSendAudios:    true,
-					CanSendVideos:    true,
-					CanSendPhotos:    true,
-					CanSendDocuments: true,
-					CanSendOther:     true,
-				},
+				User:   userCopy,
+				Rights: FullRights(),
 			}
 			if err := bot.Restrict(chat, unmuteUser); err != nil {
 				log.Printf("failed to unrestrict user %d in unmute goroutine: %v", userID, err)
@@ -113,19 +111,37 @@ func UnmuteUser(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *datab
 	}
 
 	userData.Status = "active"
+	userData.RestrictPreset = ""
+	userData.RestrictedUntil = nil
 	if err := db.SaveUser(&userData); err != nil {
 		log.Printf("UnmuteUser: Failed to save data for user %d: %v", user.User.ID, err)
 	}
-	user.Rights = tele.Rights{
-		CanSendMessages:  true,
-		CanSendMedia:     true,
-		CanSendAudios:    true,
-		CanSendVideos:    true,
-		CanSendPhotos:    true,
-		CanSendDocuments: true,
-		CanSendOther:     true,
+	user.Rights = FullRights()
+	// Размут не отменяет карантин новичка
+	if userData.Quarantined {
+		user.Rights = QuarantineRights()
+	}
+	if err := bot.Restrict(chat, user); err != nil {
+		log.Printf("UnmuteUser: failed to unrestrict user %d in chat %d: %v", user.User.ID, chat.ID, err)
+	}
+}
+
+// FullRights возвращает права обычного участника чата без ограничений. Размут, снятие карантина и
+// проверка при входе возвращают именно их, чтобы сверка с Telegram не принимала участника за рестрикт
+func FullRights() tele.Rights {
+	return tele.Rights{
+		Independent:       true,
+		CanSendMessages:   true,
+		CanSendAudios:     true,
+		CanSendDocuments:  true,
+		CanSendPhotos:     true,
+		CanSendVideos:     true,
+		CanSendVideoNotes: true,
+		CanSendVoiceNotes: true,
+		CanSendPolls:      true,
+		CanSendOther:      true,
+		CanAddPreviews:    true,
 	}
-	bot.Restrict(chat, user)
 }
 
 // Установить админский преф с минимальными правами
@@ -180,23 +196,25 @@ func RemovePrefTest(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *d
 	return nil
 }
 
-// Забрать все права, кроме обычных сообщений
-func RestrictUser(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *database.PostgresRepository) error {
+// Рестриктнуть юзера: забрать права по пресету (см. RestrictionRights) на minutes минут, 0 - бессрочно
+func RestrictUser(bot *tele.Bot, chat *tele.Chat, user *tele.ChatMember, db *database.PostgresRepository, preset string, minutes uint) error {
 	userData, err := db.GetUser(user.User.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get user %d: %w", user.User.ID, err)
 	}
 	userData.Status = "restricted"
-	db.SaveUser(&userData)
-	user.Rights = tele.Rights{
-		CanSendMessages:  true,
-		CanSendMedia:     false,
-		CanSendAudios:    false,
-		CanSendVideos:    false,
-		CanSendPhotos:    false,
-		CanSendDocuments: false,
-		CanSendOther:     false,
+	userData.RestrictPreset = preset
+	userData.RestrictedUntil = nil
+	user.RestrictedUntil = tele.Forever()
+	if minutes > 0 {
+		until := time.Now().In(database.MoscowTZ).Add(time.Duration(minutes) * time.Minute)
+		userData.RestrictedUntil = &until
+		user.RestrictedUntil = until.Unix()
+	}
+	if err := db.SaveUser(&userData); err != nil {
+		return fmt.Errorf("failed to save restricted status for user %d: %w", user.User.ID, err)
 	}
+	user.Rights = RestrictionRights(preset)
 	err = bot.Restrict(chat, user)
 	if err != nil {
 		return fmt.Errorf("failed to restrict user %d: %w", user.User.ID, err)
@@ -235,6 +253,12 @@ func UnmuteUsersByTime(bot *tele.Bot, chat *tele.Chat, db *database.PostgresRepo
 		chatMember, err := bot.ChatMemberOf(chat, &tele.User{ID: user.UserID})
 		if err != nil {
 			log.Printf("failed to get chat member of user %d: %v", user.UserID, err)
+			// Пользователя нет в чате - больше не пытаемся его размутить, права вернутся при повторном входе
+			if isUserNotFound(err) {
+				if err := db.SetUserLeftChat(user.UserID, true); err != nil {
+					log.Printf("failed to mark user %d as left: %v", user.UserID, err)
+				}
+			}
 			continue
 		}
 		UnmuteUser(bot, chat, chatMember, db)
@@ -244,3 +268,60 @@ func UnmuteUsersByTime(bot *tele.Bot, chat *tele.Chat, db *database.PostgresRepo
 		db.SaveChannel(&channel)
 	}
 }
+
+// Снять рестрикты с истекшим сроком
+func UnrestrictUsersByTime(bot *tele.Bot, chat *tele.Chat, db *database.PostgresRepository) {
+	users, err := db.GetAllRestrictedToLift()
+	if err != nil {
+		log.Printf("failed to get users to unrestrict: %v", err)
+		return
+	}
+	for _, user := range users {
+		member := &tele.ChatMember{User: &tele.User{ID: user.UserID}, Role: tele.Member}
+		UnmuteUser(bot, chat, member, db)
+	}
+}
+
+// DemotedRights - админские права в одном чате, снятые при разжаловании, чтобы их можно было вернуть
+type DemotedRights struct {
+	ChatID    int64       `json:"chat_id"`
+	Anonymous bool        `json:"anonymous"`
+	Rights    tele.Rights `json:"rights"`
+}
+
+// Снять админские права в Telegram во всех разрешенных чатах. Возвращает снятые права
+func DemoteInChats(bot *tele.Bot, chats []int64, userID int64) []DemotedRights {
+	var demotedRights []DemotedRights
+	for _, chatID := range chats {
+		chat := &tele.Chat{ID: chatID}
+		member, err := bot.ChatMemberOf(chat, &tele.User{ID: userID})
+		if err != nil {
+			log.Printf("DemoteInChats: failed to get chat member %d in chat %d: %v", userID, chatID, err)
+			continue
+		}
+		if member.Role != tele.Administrator {
+			continue
+		}
+		// Пустые права разжалуют админа до обычного участника
+		demoted := &tele.ChatMember{User: member.User}
+		if err := bot.Promote(chat, demoted); err != nil {
+			log.Printf("DemoteInChats: failed to demote user %d in chat %d: %v", userID, chatID, err)
+			continue
+		}
+		demotedRights = append(demotedRights, DemotedRights{ChatID: chatID, Anonymous: member.Anonymous, Rights: member.Rights})
+		log.Printf("DemoteInChats: user %d demoted in chat %d", userID, chatID)
+	}
+	return demotedRights
+}
+
+// Вернуть админские права в Telegram, снятые DemoteInChats
+func PromoteInChats(bot *tele.Bot, userID int64, demotedRights []DemotedRights) {
+	for _, demoted := range demotedRights {
+		member := &tele.ChatMember{User: &tele.User{ID: userID}, Anonymous: demoted.Anonymous, Rights: demoted.Rights}
+		if err := bot.Promote(&tele.Chat{ID: demoted.ChatID}, member); err != nil {
+			log.Printf("PromoteInChats: failed to promote user %d in chat %d: %v", userID, demoted.ChatID, err)
+			continue
+		}
+		log.Printf("PromoteInChats: user %d promoted back in chat %d", userID, demoted.ChatID)
+	}
+}
@@ -0,0 +1,160 @@
+package admins
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"saxbot/database"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Вымести пользователя из блоклиста из всех разрешенных чатов, возвращает количество чатов, где он был забанен
+func SweepBlocklisted(bot *tele.Bot, chats []int64, userID int64, db *database.PostgresRepository) int {
+	swept := 0
+	for _, chatID := range chats {
+		chat := &tele.Chat{ID: chatID}
+		member, err := bot.ChatMemberOf(chat, &tele.User{ID: userID})
+		if err != nil {
+			log.Printf("SweepBlocklisted: failed to get chat member %d in chat %d: %v", userID, chatID, err)
+			continue
+		}
+		switch member.Role {
+		case tele.Member, tele.Restricted:
+			BanUser(bot, chat, member, db)
+			swept++
+		case tele.Administrator, tele.Creator:
+			log.Printf("SweepBlocklisted: user %d is admin in chat %d, skipping", userID, chatID)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	return swept
+}
+
+// Снять бан с пользователя во всех разрешенных чатах после удаления из блоклиста
+func UnsweepBlocklisted(bot *tele.Bot, chats []int64, userID int64, db *database.PostgresRepository) {
+	for _, chatID := range chats {
+		chat := &tele.Chat{ID: chatID}
+		if err := bot.Unban(chat, &tele.User{ID: userID}, true); err != nil {
+			log.Printf("UnsweepBlocklisted: failed to unban user %d in chat %d: %v", userID, chatID, err)
+		}
+	}
+	userData, err := db.GetUser(userID)
+	if err != nil {
+		log.Printf("UnsweepBlocklisted: failed to get user %d: %v", userID, err)
+		return
+	}
+	if userData.Status == "banned" {
+		userData.Status = "active"
+		if err := db.SaveUser(&userData); err != nil {
+			log.Printf("UnsweepBlocklisted: failed to save user %d: %v", userID, err)
+		}
+	}
+}
+
+// Выгрузить блоклист в JSON
+func ExportBlocklistJSON(entries []database.BlocklistEntry) ([]byte, error) {
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal blocklist: %w", err)
+	}
+	return data, nil
+}
+
+// Выгрузить блоклист в CSV (user_id,reason,source,added_by,created_at)
+func ExportBlocklistCSV(entries []database.BlocklistEntry) ([]byte, error) {
+	var buf bytes.Buffer
+	w := csv.NewWriter(&buf)
+	if err := w.Write([]string{"user_id", "reason", "source", "added_by", "created_at"}); err != nil {
+		return nil, fmt.Errorf("failed to write csv header: %w", err)
+	}
+	for _, entry := range entries {
+		record := []string{
+			strconv.FormatInt(entry.UserID, 10),
+			entry.Reason,
+			entry.Source,
+			strconv.FormatInt(entry.AddedBy, 10),
+			entry.CreatedAt.Format(time.RFC3339),
+		}
+		if err := w.Write(record); err != nil {
+			return nil, fmt.Errorf("failed to write csv record for user %d: %w", entry.UserID, err)
+		}
+	}
+	w.Flush()
+	if err := w.Error(); err != nil {
+		return nil, fmt.Errorf("failed to flush csv: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// Разобрать файл блоклиста (JSON или CSV, определяется по расширению имени файла)
+func ParseBlocklist(fileName string, r io.Reader) ([]database.BlocklistEntry, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read blocklist file: %w", err)
+	}
+
+	var entries []database.BlocklistEntry
+	switch {
+	case strings.HasSuffix(strings.ToLower(fileName), ".json"):
+		if err := json.Unmarshal(data, &entries); err != nil {
+			return nil, fmt.Errorf("failed to parse json blocklist: %w", err)
+		}
+	case strings.HasSuffix(strings.ToLower(fileName), ".csv"):
+		entries, err = parseBlocklistCSV(data)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unsupported blocklist file %q, expected .json or .csv", fileName)
+	}
+
+	// Даты и автора из файла не переносим — запись создается заново в этом экземпляре бота
+	for i := range entries {
+		entries[i].CreatedAt = time.Time{}
+		entries[i].UpdatedAt = time.Time{}
+		if entries[i].Source == "" {
+			entries[i].Source = "import:" + fileName
+		}
+	}
+	return entries, nil
+}
+
+func parseBlocklistCSV(data []byte) ([]database.BlocklistEntry, error) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.FieldsPerRecord = -1
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse csv blocklist: %w", err)
+	}
+
+	var entries []database.BlocklistEntry
+	for i, record := range records {
+		if len(record) == 0 {
+			continue
+		}
+		userID, err := strconv.ParseInt(strings.TrimSpace(record[0]), 10, 64)
+		if err != nil {
+			// Первая строка может быть заголовком
+			if i == 0 {
+				continue
+			}
+			return nil, fmt.Errorf("invalid user_id %q on line %d: %w", record[0], i+1, err)
+		}
+		entry := database.BlocklistEntry{UserID: userID}
+		if len(record) > 1 {
+			entry.Reason = strings.TrimSpace(record[1])
+		}
+		if len(record) > 2 {
+			entry.Source = strings.TrimSpace(record[2])
+		}
+		entries = append(entries, entry)
+	}
+	return entries, nil
+}
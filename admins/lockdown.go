@@ -0,0 +1,81 @@
+package admins
+
+import (
+	"encoding/json"
+	"fmt"
+	"saxbot/database"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Режимы закрытия чата
+const (
+	LockdownAll   = "all"   // Писать нельзя никому, кроме админов
+	LockdownMedia = "media" // Можно только текст
+)
+
+// lockdownRights возвращает права участников чата для режима закрытия
+func lockdownRights(mode string) tele.Rights {
+	if mode == LockdownMedia {
+		return tele.Rights{Independent: true, CanSendMessages: true}
+	}
+	return tele.Rights{Independent: true}
+}
+
+// LockChat меняет права участников чата по умолчанию и запоминает прежние права в базе, чтобы вернуть их
+// при открытии, в том числе после перезапуска бота. Повторное закрытие меняет режим и срок, но прежними
+// остаются права до первого закрытия. until nil - до ручного открытия
+func LockChat(bot *tele.Bot, chat *tele.Chat, mode string, until *time.Time, startedBy int64, db *database.PostgresRepository) error {
+	lockdown, err := db.GetLockdown(chat.ID)
+	if err != nil {
+		return err
+	}
+	if lockdown == nil {
+		current, err := bot.ChatByID(chat.ID)
+		if err != nil {
+			return fmt.Errorf("failed to get permissions of chat %d: %w", chat.ID, err)
+		}
+		prev := FullRights()
+		if current.Permissions != nil {
+			prev = *current.Permissions
+		}
+		data, err := json.Marshal(prev)
+		if err != nil {
+			return fmt.Errorf("failed to marshal permissions of chat %d: %w", chat.ID, err)
+		}
+		lockdown = &database.ChatLockdown{ChatID: chat.ID, PrevPermissions: string(data)}
+	}
+	lockdown.Mode = mode
+	lockdown.Until = until
+	lockdown.StartedBy = startedBy
+
+	// Сначала запоминаем прежние права, чтобы при сбое после смены прав их было откуда вернуть
+	if err := db.SaveLockdown(lockdown); err != nil {
+		return err
+	}
+	if err := bot.SetGroupPermissions(chat, lockdownRights(mode)); err != nil {
+		return fmt.Errorf("failed to lock chat %d: %w", chat.ID, err)
+	}
+	return nil
+}
+
+// UnlockChat возвращает участникам чата права, которые были до закрытия. Возвращает false, если чат не был закрыт
+func UnlockChat(bot *tele.Bot, chat *tele.Chat, db *database.PostgresRepository) (bool, error) {
+	lockdown, err := db.GetLockdown(chat.ID)
+	if err != nil {
+		return false, err
+	}
+	if lockdown == nil {
+		return false, nil
+	}
+	prev := FullRights()
+	if err := json.Unmarshal([]byte(lockdown.PrevPermissions), &prev); err != nil {
+		return false, fmt.Errorf("failed to unmarshal permissions of chat %d: %w", chat.ID, err)
+	}
+	prev.Independent = true
+	if err := bot.SetGroupPermissions(chat, prev); err != nil {
+		return false, fmt.Errorf("failed to unlock chat %d: %w", chat.ID, err)
+	}
+	return true, db.DeleteLockdown(chat.ID)
+}
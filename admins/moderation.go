@@ -0,0 +1,68 @@
+package admins
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Действия модерации, которые пишутся в журнал
+const (
+	ActionWarn      = "warn"
+	ActionBan       = "ban"
+	ActionBlocklist = "blocklist"
+	ActionKick      = "kick"
+	ActionMute      = "mute"
+	ActionRestrict  = "restrict"
+)
+
+// Откатить действие модерации и отметить его в журнале
+func RevertModerationAction(bot *tele.Bot, chats []int64, action database.ModerationAction, db *database.PostgresRepository) error {
+	actionChats := chats
+	if action.ChatID != 0 {
+		actionChats = []int64{action.ChatID}
+	}
+	user := &tele.User{ID: action.TargetID}
+
+	switch action.Action {
+	case ActionBan:
+		for _, chatID := range actionChats {
+			if err := bot.Unban(&tele.Chat{ID: chatID}, user, true); err != nil {
+				log.Printf("RevertModerationAction: failed to unban user %d in chat %d: %v", action.TargetID, chatID, err)
+			}
+		}
+		userData, err := db.GetUser(action.TargetID)
+		if err != nil {
+			return fmt.Errorf("failed to get user %d: %w", action.TargetID, err)
+		}
+		if userData.Status == "banned" {
+			userData.Status = "active"
+			if err := db.SaveUser(&userData); err != nil {
+				return fmt.Errorf("failed to save user %d: %w", action.TargetID, err)
+			}
+		}
+	case ActionBlocklist:
+		if _, err := db.RemoveFromBlocklist(action.TargetID); err != nil {
+			return fmt.Errorf("failed to remove user %d from blocklist: %w", action.TargetID, err)
+		}
+		UnsweepBlocklisted(bot, chats, action.TargetID, db)
+	case ActionMute, ActionRestrict:
+		for _, chatID := range actionChats {
+			UnmuteUser(bot, &tele.Chat{ID: chatID}, &tele.ChatMember{User: user, Role: tele.Member}, db)
+		}
+	case ActionWarn:
+		if err := db.UpdateUserWarns(action.TargetID, -1); err != nil {
+			return fmt.Errorf("failed to decrease warns of user %d: %w", action.TargetID, err)
+		}
+	case ActionKick:
+		// Кик не оставляет бана, пользователь может вернуться сам
+	}
+
+	if err := db.MarkActionReverted(action.ID); err != nil {
+		return err
+	}
+	log.Printf("RevertModerationAction: %s of user %d by admin %d reverted", action.Action, action.TargetID, action.AdminID)
+	return nil
+}
@@ -0,0 +1,66 @@
+package admins
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// QuarantineRights возвращает права новичка на карантине: только текст, без медиа и превью ссылок.
+// Пересылки и ссылки Telegram запретить не умеет, такие сообщения удаляет бот
+func QuarantineRights() tele.Rights {
+	return tele.Rights{
+		Independent:     true,
+		CanSendMessages: true,
+	}
+}
+
+// StartQuarantine отправляет новичка на карантин. Срок в часах (0 - без срока) записывается в базу,
+// лимит сообщений проверяет обработчик чата. Права в чате выдает вызывающий код
+func StartQuarantine(user *database.User, hours int) {
+	user.Quarantined = true
+	user.QuarantineMessages = 0
+	user.QuarantineUntil = nil
+	if hours > 0 {
+		until := time.Now().Add(time.Duration(hours) * time.Hour)
+		user.QuarantineUntil = &until
+	}
+}
+
+// ReleaseQuarantine снимает карантин и возвращает пользователю все права.
+// Если пользователь тем временем замучен или в рестрикте, права не трогаем: их вернет размут
+func ReleaseQuarantine(bot *tele.Bot, chat *tele.Chat, user *database.User, db *database.PostgresRepository) error {
+	user.Quarantined = false
+	user.QuarantineUntil = nil
+	user.QuarantineMessages = 0
+	if err := db.SaveUser(user); err != nil {
+		return fmt.Errorf("failed to release user %d from quarantine: %w", user.UserID, err)
+	}
+	if user.Status != "active" {
+		return nil
+	}
+	member := &tele.ChatMember{User: &tele.User{ID: user.UserID}, Role: tele.Member, Rights: FullRights()}
+	if err := bot.Restrict(chat, member); err != nil {
+		return fmt.Errorf("failed to restore rights of user %d after quarantine: %w", user.UserID, err)
+	}
+	return nil
+}
+
+// Снять карантин с новичков, у которых он истек по времени
+func ReleaseQuarantinesByTime(bot *tele.Bot, chat *tele.Chat, db *database.PostgresRepository) {
+	users, err := db.GetQuarantinesToLift()
+	if err != nil {
+		log.Printf("failed to get users to release from quarantine: %v", err)
+		return
+	}
+	for _, user := range users {
+		if err := ReleaseQuarantine(bot, chat, &user, db); err != nil {
+			log.Printf("ReleaseQuarantinesByTime: %v", err)
+			continue
+		}
+		log.Printf("User %d released from quarantine by time", user.UserID)
+	}
+}
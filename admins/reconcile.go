@@ -0,0 +1,209 @@
+package admins
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Результат сверки статусов пользователей в базе с их правами в Telegram
+type ReconcileResult struct {
+	Checked int      // Сколько пользователей проверено
+	Left    int      // Сколько пользователей отмечено вышедшими из чата
+	Fixed   []string // Описания исправленных расхождений
+	Failed  []string // Расхождения, которые не удалось исправить
+}
+
+// HasChanges сообщает, есть ли что отправить главному админу
+func (r ReconcileResult) HasChanges() bool {
+	return len(r.Fixed) > 0 || len(r.Failed) > 0
+}
+
+// Report формирует отчет о сверке для главного админа
+func (r ReconcileResult) Report() string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Сверка наказаний с Telegram: проверено %d, вышли из чата %d\n", r.Checked, r.Left))
+	if len(r.Fixed) > 0 {
+		sb.WriteString("\nИсправлено:\n")
+		for _, line := range r.Fixed {
+			sb.WriteString("- " + line + "\n")
+		}
+	}
+	if len(r.Failed) > 0 {
+		sb.WriteString("\nНе удалось исправить:\n")
+		for _, line := range r.Failed {
+			sb.WriteString("- " + line + "\n")
+		}
+	}
+	return sb.String()
+}
+
+// isUserNotFound проверяет, что Telegram не знает пользователя как участника чата
+func isUserNotFound(err error) bool {
+	text := strings.ToLower(err.Error())
+	return strings.Contains(text, "user not found") || strings.Contains(text, "participant_id_invalid")
+}
+
+// telegramStatus переводит права участника в Telegram в статус пользователя, как он хранится в базе,
+// и для рестрикта - в пресет. Сравниваются только права, которые забирают пресеты рестрикта
+func telegramStatus(member *tele.ChatMember) (string, string) {
+	switch member.Role {
+	case tele.Kicked:
+		return "banned", ""
+	case tele.Restricted:
+		if !member.CanSendMessages {
+			return "muted", ""
+		}
+		if !member.CanSendPhotos || !member.CanSendVideos || !member.CanSendAudios || !member.CanSendDocuments {
+			return "restricted", RestrictMedia
+		}
+		if !member.CanSendOther {
+			return "restricted", RestrictStickers
+		}
+		// Старый размут не выдавал превью вместе с опросами и кружками, такие права рестриктом не считаем
+		if !member.CanAddPreviews && member.CanSendPolls {
+			return "restricted", RestrictLinks
+		}
+	}
+	return "active", ""
+}
+
+// expectedStatus возвращает статус, который должен быть в Telegram по данным базы
+func expectedStatus(user database.User) string {
+	if user.Status == "muted" && !user.MutedUntil.IsZero() && user.MutedUntil.Before(time.Now()) {
+		return "active"
+	}
+	if user.Status == "restricted" && user.RestrictedUntil != nil && user.RestrictedUntil.Before(time.Now()) {
+		return "active"
+	}
+	switch user.Status {
+	case "muted", "restricted", "banned":
+		return user.Status
+	}
+	// У новичка на карантине забраны медиа, в Telegram это выглядит как рестрикт
+	if user.Quarantined {
+		return "restricted"
+	}
+	return "active"
+}
+
+// Сверить статусы пользователей в базе с их правами в чате и исправить расхождения в обе стороны.
+// Если в базе наказания нет, а в Telegram есть (выдано вручную) - обновляем базу.
+// Если наказание есть в базе, но не применилось в Telegram - применяем его заново.
+// Вышедших из чата отмечаем, чтобы больше их не проверять
+func ReconcileSanctions(bot *tele.Bot, chat *tele.Chat, db *database.PostgresRepository) (ReconcileResult, error) {
+	var result ReconcileResult
+	users, err := db.GetUsersToReconcile()
+	if err != nil {
+		return result, fmt.Errorf("failed to get users to reconcile: %w", err)
+	}
+
+	for _, user := range users {
+		time.Sleep(100 * time.Millisecond)
+		result.Checked++
+		name := fmt.Sprintf("@%s (%d)", user.Username, user.UserID)
+		if user.Username == "" {
+			name = fmt.Sprintf("%s (%d)", user.FirstName, user.UserID)
+		}
+
+		member, err := bot.ChatMemberOf(chat, &tele.User{ID: user.UserID})
+		if err != nil {
+			if !isUserNotFound(err) {
+				log.Printf("ReconcileSanctions: failed to get chat member %d: %v", user.UserID, err)
+				continue
+			}
+			member = &tele.ChatMember{Role: tele.Left}
+		}
+		if member.Role == tele.Administrator || member.Role == tele.Creator {
+			continue
+		}
+		member.User = &tele.User{ID: user.UserID, Username: user.Username, FirstName: user.FirstName}
+
+		actual, actualPreset := telegramStatus(member)
+		expected := expectedStatus(user)
+		left := member.Role == tele.Left || member.Role == tele.Kicked || (member.Role == tele.Restricted && !member.Member)
+
+		switch {
+		case actual == expected:
+			// Расхождений нет
+		case expected == "active" && user.Status == actual:
+			// Мут или рестрикт истек, но права не вернулись
+			UnmuteUser(bot, chat, member, db)
+			result.Fixed = append(result.Fixed, fmt.Sprintf("%s: %s истек, права в чате возвращены", name, actual))
+		case expected == "active" && actual != "active":
+			// Наказание выдано в Telegram вручную, в базе его нет
+			user.Status = actual
+			if actual == "restricted" {
+				user.RestrictPreset = actualPreset
+				user.RestrictedUntil = nil
+				if member.RestrictedUntil > 0 {
+					until := time.Unix(member.RestrictedUntil, 0)
+					user.RestrictedUntil = &until
+				}
+			}
+			if actual == "muted" {
+				user.MutedUntil = time.Time{}
+				if member.RestrictedUntil > 0 {
+					user.MutedUntil = time.Unix(member.RestrictedUntil, 0)
+				}
+			}
+			if err := db.SaveUser(&user); err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("%s: не удалось сохранить статус %s: %v", name, actual, err))
+				continue
+			}
+			result.Fixed = append(result.Fixed, fmt.Sprintf("%s: в Telegram %s, статус в базе обновлен", name, actual))
+		case left && expected != "banned":
+			// Наказание вернется при повторном входе (см. HandleUserJoined)
+		default:
+			// Наказание есть в базе, но не применилось в Telegram
+			if err := applyStatus(bot, chat, member, user); err != nil {
+				result.Failed = append(result.Failed, fmt.Sprintf("%s: в базе %s, в Telegram %s: %v", name, expected, actual, err))
+				continue
+			}
+			result.Fixed = append(result.Fixed, fmt.Sprintf("%s: в базе %s, в Telegram было %s, наказание применено заново", name, expected, actual))
+		}
+
+		if left {
+			if err := db.SetUserLeftChat(user.UserID, true); err != nil {
+				log.Printf("ReconcileSanctions: %v", err)
+				continue
+			}
+			result.Left++
+		}
+	}
+	return result, nil
+}
+
+// applyStatus применяет в Telegram наказание, записанное в базе
+func applyStatus(bot *tele.Bot, chat *tele.Chat, member *tele.ChatMember, user database.User) error {
+	switch user.Status {
+	case "banned":
+		return bot.Ban(chat, member)
+	case "muted":
+		member.Rights = tele.Rights{CanSendMessages: false}
+		if !user.MutedUntil.IsZero() {
+			member.RestrictedUntil = user.MutedUntil.Unix()
+		} else {
+			member.RestrictedUntil = tele.Forever()
+		}
+		return bot.Restrict(chat, member)
+	case "active":
+		if user.Quarantined {
+			member.Rights = QuarantineRights()
+			member.RestrictedUntil = tele.Forever()
+			return bot.Restrict(chat, member)
+		}
+	case "restricted":
+		member.Rights = RestrictionRights(user.RestrictPreset)
+		member.RestrictedUntil = tele.Forever()
+		if user.RestrictedUntil != nil {
+			member.RestrictedUntil = user.RestrictedUntil.Unix()
+		}
+		return bot.Restrict(chat, member)
+	}
+	return nil
+}
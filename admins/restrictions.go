@@ -0,0 +1,68 @@
+package admins
+
+import (
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Пресеты рестрикта: какие права забираются у пользователя
+const (
+	RestrictMedia    = "media"    // Только текст, без медиа (по умолчанию)
+	RestrictStickers = "stickers" // Без стикеров, гифок и инлайн-ботов
+	RestrictLinks    = "links"    // Без ссылок: превью отключены, сообщения со ссылками удаляет бот
+)
+
+// Слова в команде рестрикта, которые выбирают пресет
+var restrictPresetWords = map[string]string{
+	"медиа":   RestrictMedia,
+	"стикеры": RestrictStickers,
+	"гифки":   RestrictStickers,
+	"ссылки":  RestrictLinks,
+}
+
+// ParseRestrictPreset возвращает пресет по слову из команды. Второе значение false, если слово не пресет
+func ParseRestrictPreset(word string) (string, bool) {
+	preset, ok := restrictPresetWords[strings.ToLower(word)]
+	return preset, ok
+}
+
+// RestrictPresetTitle возвращает название пресета для сообщений в чате
+func RestrictPresetTitle(preset string) string {
+	switch preset {
+	case RestrictStickers:
+		return "без стикеров и гифок"
+	case RestrictLinks:
+		return "без ссылок"
+	}
+	return "без медиа"
+}
+
+// RestrictionRights возвращает права пользователя в чате для пресета рестрикта
+func RestrictionRights(preset string) tele.Rights {
+	rights := FullRights()
+	switch preset {
+	case RestrictStickers:
+		rights.CanSendOther = false
+	case RestrictLinks:
+		rights.CanAddPreviews = false
+	default:
+		rights = tele.Rights{
+			Independent:     true,
+			CanSendMessages: true,
+		}
+	}
+	return rights
+}
+
+// HasLinks проверяет, есть ли в сообщении ссылки
+func HasLinks(msg *tele.Message) bool {
+	for _, entities := range []tele.Entities{msg.Entities, msg.CaptionEntities} {
+		for _, entity := range entities {
+			if entity.Type == tele.EntityURL || entity.Type == tele.EntityTextLink {
+				return true
+			}
+		}
+	}
+	return false
+}
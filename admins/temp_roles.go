@@ -0,0 +1,112 @@
+package admins
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Чаты, в которых ставится тег временной роли: ее чат или все разрешенные чаты
+func tempRoleChats(role database.TempRole, chats []int64) []int64 {
+	if role.ChatID != 0 {
+		return []int64{role.ChatID}
+	}
+	return chats
+}
+
+// Выдать временную роль и поставить ее тег в чатах
+func GrantTempRole(bot *tele.Bot, chats []int64, role *database.TempRole, db *database.PostgresRepository) error {
+	if err := db.SaveTempRole(role); err != nil {
+		return err
+	}
+	if role.Tag == "" {
+		return nil
+	}
+	member := &tele.ChatMember{User: &tele.User{ID: role.UserID}, Role: tele.Member}
+	for _, chatID := range tempRoleChats(*role, chats) {
+		SetPref(bot, &tele.Chat{ID: chatID}, member, role.Tag)
+	}
+	return nil
+}
+
+// Снять временную роль и ее тег. Если у пользователя осталась другая роль с тегом, ставим ее тег
+func RevokeTempRole(bot *tele.Bot, chats []int64, role database.TempRole, db *database.PostgresRepository) error {
+	if err := db.DeleteTempRole(role.ID); err != nil {
+		return err
+	}
+	log.Printf("RevokeTempRole: temp role %s of user %d revoked", role.Kind, role.UserID)
+	if role.Tag == "" {
+		return nil
+	}
+
+	nextTag := ""
+	remaining, err := db.GetActiveTempRoles(role.UserID)
+	if err != nil {
+		log.Printf("RevokeTempRole: failed to get remaining temp roles of user %d: %v", role.UserID, err)
+	}
+	for _, other := range remaining {
+		if other.Tag != "" {
+			nextTag = other.Tag
+		}
+	}
+
+	for _, chatID := range tempRoleChats(role, chats) {
+		_, err := bot.Raw("setChatMemberTag", map[string]any{
+			"chat_id": chatID,
+			"user_id": role.UserID,
+			"tag":     nextTag, // пустой тег удаляет тег
+		})
+		if err != nil {
+			log.Printf("RevokeTempRole: failed to update chat member tag for user %d in chat %d: %v", role.UserID, chatID, err)
+		}
+	}
+	return nil
+}
+
+// Снять все временные роли пользователя, возвращает количество снятых ролей
+func RevokeUserTempRoles(bot *tele.Bot, chats []int64, userID int64, db *database.PostgresRepository) (int, error) {
+	roles, err := db.GetUserTempRoles(userID)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, role := range roles {
+		if err := RevokeTempRole(bot, chats, role, db); err != nil {
+			return revoked, fmt.Errorf("failed to revoke temp role %d: %w", role.ID, err)
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// Снять все временные роли одного назначения, возвращает количество снятых ролей
+func RevokeTempRolesByKind(bot *tele.Bot, chats []int64, kind string, db *database.PostgresRepository) (int, error) {
+	roles, err := db.GetTempRolesByKind(kind)
+	if err != nil {
+		return 0, err
+	}
+	revoked := 0
+	for _, role := range roles {
+		if err := RevokeTempRole(bot, chats, role, db); err != nil {
+			return revoked, fmt.Errorf("failed to revoke temp role %d: %w", role.ID, err)
+		}
+		revoked++
+	}
+	return revoked, nil
+}
+
+// Снять временные роли с истекшим сроком (вызывается по таймеру)
+func RevokeExpiredTempRoles(bot *tele.Bot, chats []int64, db *database.PostgresRepository) {
+	roles, err := db.GetExpiredTempRoles()
+	if err != nil {
+		log.Printf("RevokeExpiredTempRoles: %v", err)
+		return
+	}
+	for _, role := range roles {
+		if err := RevokeTempRole(bot, chats, role, db); err != nil {
+			log.Printf("RevokeExpiredTempRoles: failed to revoke temp role %d of user %d: %v", role.ID, role.UserID, err)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package database
+
+import "fmt"
+
+// Добавить вариант названия песни
+func (p *PostgresRepository) AddSongAlias(alias *SongAlias) error {
+	err := p.db.Create(alias).Error
+	if err != nil {
+		return fmt.Errorf("failed to add alias %q for song %q: %w", alias.Alias, alias.SongName, err)
+	}
+	return nil
+}
+
+// Получить варианты названия песни по нормализованному названию
+func (p *PostgresRepository) GetSongAliases(songName string) ([]SongAlias, error) {
+	var aliases []SongAlias
+	err := p.db.Where("song_name = ?", songName).Order("id").Find(&aliases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get aliases of song %q: %w", songName, err)
+	}
+	return aliases, nil
+}
+
+// Получить все варианты названий песен
+func (p *PostgresRepository) GetAllSongAliases() ([]SongAlias, error) {
+	var aliases []SongAlias
+	err := p.db.Order("song_name, id").Find(&aliases).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get song aliases: %w", err)
+	}
+	return aliases, nil
+}
+
+// Удалить вариант названия песни. Возвращает false, если такого нет
+func (p *PostgresRepository) DeleteSongAlias(id uint) (bool, error) {
+	result := p.db.Where("id = ?", id).Delete(&SongAlias{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to delete song alias %d: %w", id, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"gorm.io/gorm/clause"
+)
+
+// Сохранить сообщение в архив (повторное сохранение того же сообщения игнорируется)
+func (p *PostgresRepository) ArchiveMessage(msg *ArchivedMessage) error {
+	err := p.db.Clauses(clause.OnConflict{DoNothing: true}).Create(msg).Error
+	if err != nil {
+		return fmt.Errorf("failed to archive message %d in chat %d: %w", msg.MessageID, msg.ChatID, err)
+	}
+	return nil
+}
+
+// Найти сообщения в архиве по автору (0 - любой) и слову (пустое - любое), от новых к старым
+func (p *PostgresRepository) SearchArchive(senderID int64, keyword string, limit int) ([]ArchivedMessage, error) {
+	var messages []ArchivedMessage
+	query := p.db.Model(&ArchivedMessage{})
+	if senderID != 0 {
+		query = query.Where("sender_id = ?", senderID)
+	}
+	if keyword != "" {
+		// Экранируем спецсимволы LIKE, чтобы искать слово как есть
+		escaped := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(keyword)
+		query = query.Where("text ILIKE ?", "%"+escaped+"%")
+	}
+	err := query.Order("sent_at DESC").Limit(limit).Find(&messages).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to search archive: %w", err)
+	}
+	return messages, nil
+}
+
+// Удалить из архива сообщения старше before, возвращает количество удаленных
+func (p *PostgresRepository) PurgeArchive(before time.Time) (int64, error) {
+	result := p.db.Where("sent_at < ?", before).Delete(&ArchivedMessage{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge archive: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,87 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Добавить пользователя в блоклист (или обновить существующую запись)
+func (p *PostgresRepository) AddToBlocklist(entry BlocklistEntry) error {
+	err := p.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason", "source", "added_by", "updated_at"}),
+	}).Create(&entry).Error
+	if err != nil {
+		return fmt.Errorf("failed to add user %d to blocklist: %w", entry.UserID, err)
+	}
+	log.Printf("User %d added to blocklist (source: %s)", entry.UserID, entry.Source)
+	return nil
+}
+
+// Импортировать записи в блоклист, возвращает количество новых записей
+func (p *PostgresRepository) ImportBlocklist(entries []BlocklistEntry) (int, error) {
+	added := 0
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		for _, entry := range entries {
+			if entry.UserID == 0 {
+				continue
+			}
+			result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&entry)
+			if result.Error != nil {
+				return fmt.Errorf("failed to import user %d: %w", entry.UserID, result.Error)
+			}
+			added += int(result.RowsAffected)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to import blocklist: %w", err)
+	}
+	return added, nil
+}
+
+// Удалить пользователя из блоклиста, возвращает false, если записи не было
+func (p *PostgresRepository) RemoveFromBlocklist(userID int64) (bool, error) {
+	result := p.db.Where("user_id = ?", userID).Delete(&BlocklistEntry{})
+	if result.Error != nil {
+		return false, fmt.Errorf("failed to remove user %d from blocklist: %w", userID, result.Error)
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Получить запись блоклиста по ID пользователя (nil, если пользователя нет в блоклисте)
+func (p *PostgresRepository) GetBlocklistEntry(userID int64) (*BlocklistEntry, error) {
+	var entry BlocklistEntry
+	err := p.db.Where("user_id = ?", userID).First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist entry for user %d: %w", userID, err)
+	}
+	return &entry, nil
+}
+
+// Проверить, находится ли пользователь в блоклисте
+func (p *PostgresRepository) IsBlocklisted(userID int64) bool {
+	entry, err := p.GetBlocklistEntry(userID)
+	if err != nil {
+		log.Printf("failed to figure out if user %d is blocklisted: %v", userID, err)
+		return false
+	}
+	return entry != nil
+}
+
+// Получить весь блоклист
+func (p *PostgresRepository) GetBlocklist() ([]BlocklistEntry, error) {
+	var entries []BlocklistEntry
+	err := p.db.Order("created_at ASC").Find(&entries).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get blocklist: %w", err)
+	}
+	return entries, nil
+}
@@ -8,17 +8,24 @@ import (
 
 // User представляет пользователя бота в Postgres
 type User struct {
-	UserID       int64          `gorm:"primaryKey" json:"user_id"`
-	FirstName    string         `gorm:"size:255" json:"first_name"`
-	Username     string         `gorm:"size:255" json:"username"`
-	Warns        int            `gorm:"default:0" json:"warns"`
-	Status       string         `gorm:"size:50;default:'active'" json:"status"`
-	MessageCount int            `gorm:"default:0" json:"message_count"`
-	MutedUntil   time.Time      `gorm:"default:null" json:"muted_until"`
-	Birthday     time.Time      `gorm:"default:null" json:"birthday"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	UserID             int64          `gorm:"primaryKey" json:"user_id"`
+	FirstName          string         `gorm:"size:255" json:"first_name"`
+	Username           string         `gorm:"size:255" json:"username"`
+	Warns              int            `gorm:"default:0" json:"warns"`
+	Status             string         `gorm:"size:50;default:'active'" json:"status"`
+	MessageCount       int            `gorm:"default:0" json:"message_count"`
+	MutedUntil         time.Time      `gorm:"default:null" json:"muted_until"`
+	RestrictedUntil    *time.Time     `gorm:"default:null" json:"restricted_until,omitempty"` // До какого времени действует рестрикт (nil - бессрочно)
+	RestrictPreset     string         `gorm:"size:50" json:"restrict_preset"`                 // Какие права забраны рестриктом (см. admins.RestrictionRights)
+	Birthday           time.Time      `gorm:"default:null" json:"birthday"`
+	VerifiedAt         *time.Time     `gorm:"default:null" json:"verified_at,omitempty"`      // Когда прошел проверку "Я не бот!"
+	LeftChat           bool           `gorm:"default:false" json:"left_chat"`                 // Вышел из чата, сверка и размут по таймеру его пропускают
+	Quarantined        bool           `gorm:"default:false" json:"quarantined"`               // Новичок на карантине: без медиа, ссылок и пересылок
+	QuarantineUntil    *time.Time     `gorm:"default:null" json:"quarantine_until,omitempty"` // Когда карантин снимется по времени (nil - только по сообщениям)
+	QuarantineMessages int            `gorm:"default:0" json:"quarantine_messages"`           // Сколько сообщений написано на карантине
+	CreatedAt          time.Time      `json:"created_at"`
+	UpdatedAt          time.Time      `json:"updated_at"`
+	DeletedAt          gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
 }
 
 // Channel представляет каналы в Postgres
@@ -35,26 +42,76 @@ type Channel struct {
 
 // Quiz представляет данные квиза в Postgres
 type Quiz struct {
-	ID         uint           `gorm:"primaryKey" json:"id"`
-	Date       time.Time      `gorm:"uniqueIndex;not null" json:"date"` // Дата квиза (без времени)
-	Quote      string         `gorm:"type:text" json:"quote"`
-	SongName   string         `gorm:"size:500" json:"song_name"`
-	QuizTime   time.Time      `gorm:"not null" json:"quiz_time"` // Время проведения квиза
-	IsActive   bool           `gorm:"default:true" json:"is_active"`
-	WinnerID   int64          `gorm:"default:0" json:"winner_id"`
-	Winner     User           `gorm:"foreignKey:WinnerID;references:UserID" json:"winner,omitempty"`
-	IsClip     bool           `gorm:"default:false" json:"is_clip"`
-	ScreenPath string         `gorm:"size:500" json:"screen_path"`
-	CreatedAt  time.Time      `json:"created_at"`
-	UpdatedAt  time.Time      `json:"updated_at"`
-	DeletedAt  gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	ID            uint           `gorm:"primaryKey" json:"id"`
+	Date          time.Time      `gorm:"uniqueIndex;not null" json:"date"` // Дата квиза (без времени)
+	Quote         string         `gorm:"type:text" json:"quote"`
+	SongName      string         `gorm:"size:500" json:"song_name"`
+	QuizTime      time.Time      `gorm:"not null" json:"quiz_time"` // Время проведения квиза
+	IsActive      bool           `gorm:"default:true" json:"is_active"`
+	WinnerID      int64          `gorm:"default:0" json:"winner_id"`
+	Winner        User           `gorm:"foreignKey:WinnerID;references:UserID" json:"winner,omitempty"`
+	ScreenPath    string         `gorm:"size:500" json:"screen_path"`
+	FileID        string         `gorm:"size:255" json:"file_id"`          // Telegram file_id кадра, загруженного через бота
+	ItemID        uint           `gorm:"default:0" json:"item_id"`         // Вопрос из quiz_items (0 - из встроенных списков)
+	Kind          string         `gorm:"size:50" json:"kind"`              // Вид квиза: quote, clip, poll, lyrics или emoji
+	Answer        string         `gorm:"size:500" json:"answer"`           // Ожидаемый ответ: название песни или пропущенные слова
+	PollID        string         `gorm:"size:255;index" json:"poll_id"`    // ID опроса Telegram для квиза-опроса
+	PollMessageID int            `gorm:"default:0" json:"poll_message_id"` // Сообщение с опросом, чтобы закрыть его
+	PollCorrect   int            `gorm:"default:0" json:"poll_correct"`    // Номер правильного варианта в опросе
+	CreatedAt     time.Time      `json:"created_at"`
+	UpdatedAt     time.Time      `json:"updated_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+}
+
+// QuizItem представляет вопрос квиза в Postgres: цитату из песни, кадр из клипа или эмодзи-ребус
+type QuizItem struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Kind       string    `gorm:"size:50;index;not null" json:"kind"` // quote, screen или emoji
+	Quote      string    `gorm:"type:text" json:"quote"`             // Цитата или эмодзи-ребус
+	ScreenPath string    `gorm:"size:500" json:"screen_path"`        // Кадр на диске (импортирован из images/clips)
+	FileID     string    `gorm:"size:255" json:"file_id"`            // Кадр, присланный главным админом боту
+	SongName   string    `gorm:"size:500;not null" json:"song_name"`
+	Difficulty int       `gorm:"default:1" json:"difficulty"` // 1 - легко, 2 - средне, 3 - сложно
+	Enabled    bool      `gorm:"default:true" json:"enabled"`
+	AddedBy    int64     `gorm:"default:0" json:"added_by"` // 0 - импорт встроенных списков
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// QuizPoint представляет очки, начисленные пользователю за квиз
+type QuizPoint struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	QuizID    uint      `gorm:"index;not null" json:"quiz_id"`
+	UserID    int64     `gorm:"index;not null" json:"user_id"`
+	Points    int       `gorm:"not null" json:"points"`
+	Rank      int       `gorm:"default:0" json:"rank"`      // Место среди правильно ответивших (1 - первый)
+	Reason    string    `gorm:"size:50" json:"reason"`      // За что начислены очки: win, speed, streak или poll
+	Season    string    `gorm:"size:7;index" json:"season"` // Месячный сезон в формате 2006-01
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// QuizSeasonResult представляет итоговое место пользователя в завершенном сезоне квиза
+type QuizSeasonResult struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Season    string    `gorm:"size:7;index;not null" json:"season"`
+	Rank      int       `gorm:"not null" json:"rank"`
+	UserID    int64     `gorm:"index;not null" json:"user_id"`
+	Points    int       `gorm:"not null" json:"points"`
+	CreatedAt time.Time `json:"created_at"`
 }
 
 // Admin представляет админов в Postgres
 type Admin struct {
 	ID        int64  `gorm:"primaryKey" json:"id"`
 	User      User   `gorm:"foreignKey:ID;references:UserID" json:"admin,omitempty"`
-	AdminRole string `gorm:"size:500,default:'junior'" json:"admin_role"` // Два уровня - junior и senior. Отличаются возможностью банить
+	AdminRole string `gorm:"size:500,default:'junior'" json:"admin_role"` // Имя роли из таблицы admin_roles
+}
+
+// Role представляет именованную роль админа с набором разрешений в Postgres
+type Role struct {
+	Name        string `gorm:"primaryKey;size:100" json:"name"`
+	Rank        int    `gorm:"default:0" json:"rank"`        // Порядок ролей: /promote и /demote двигают админа по рангам
+	Permissions string `gorm:"type:text" json:"permissions"` // Разрешения через запятую, например "warn,mute,restrict"
 }
 
 type Audio struct {
@@ -84,6 +141,141 @@ type Horoscope struct {
 	Pisces      string `gorm:"type:text" json:"pisces"`
 }
 
+// BlocklistEntry представляет запись глобального блоклиста в Postgres
+type BlocklistEntry struct {
+	UserID    int64     `gorm:"primaryKey" json:"user_id"`
+	Reason    string    `gorm:"type:text" json:"reason"`
+	Source    string    `gorm:"size:255" json:"source"` // Откуда пришла запись: бот, чат или файл импорта
+	AddedBy   int64     `gorm:"default:0" json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// TempRole представляет временную роль пользователя с набором разрешений, тегом и сроком действия в Postgres
+type TempRole struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	UserID      int64      `gorm:"index;not null" json:"user_id"`
+	ChatID      int64      `gorm:"default:0" json:"chat_id"`          // Чат для тега, 0 - все разрешенные чаты
+	Kind        string     `gorm:"size:50;index" json:"kind"`         // Назначение роли: quiz_winner, manual и т.п.
+	Permissions string     `gorm:"type:text" json:"permissions"`      // Разрешения через запятую
+	Tag         string     `gorm:"size:16" json:"tag"`                // Тег участника в чате, 0–16 символов
+	GrantedBy   int64      `gorm:"default:0" json:"granted_by"`       // Кто выдал роль, 0 - бот
+	ExpiresAt   *time.Time `gorm:"index" json:"expires_at,omitempty"` // nil - до отзыва (например, до следующего квиза)
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// ModerationAction представляет запись журнала действий модерации в Postgres
+type ModerationAction struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	AdminID         int64     `gorm:"index;not null" json:"admin_id"` // Кто выполнил действие (пользователь или канал)
+	TargetID        int64     `gorm:"index;not null" json:"target_id"`
+	ChatID          int64     `gorm:"default:0" json:"chat_id"` // 0 - все разрешенные чаты (блоклист)
+	Action          string    `gorm:"size:50;index" json:"action"`
+	DurationMinutes uint      `gorm:"default:0" json:"duration_minutes"` // 0 - бессрочно
+	Reverted        bool      `gorm:"default:false" json:"reverted"`
+	Acknowledged    bool      `gorm:"default:false" json:"acknowledged"` // Учтено при восстановлении прав, в лимиты больше не идет
+	EvidenceMsgID   int       `gorm:"default:0" json:"evidence_msg_id"`  // Сообщение, за которое наказали (0 - не указано)
+	EvidenceText    string    `gorm:"type:text" json:"evidence_text"`    // Копия текста сообщения, живет дольше архива
+	CreatedAt       time.Time `gorm:"index" json:"created_at"`
+}
+
+// AdminSuspension представляет приостановку админских прав после превышения лимитов в Postgres
+type AdminSuspension struct {
+	UserID        int64     `gorm:"primaryKey" json:"user_id"`
+	Reason        string    `gorm:"type:text" json:"reason"`
+	DemotedRights string    `gorm:"type:text" json:"demoted_rights"` // Снятые в Telegram админские права (JSON), чтобы вернуть их
+	CreatedAt     time.Time `json:"created_at"`
+}
+
+// AdminReport представляет вызов админов командой "админ" и ответ на него в Postgres
+type AdminReport struct {
+	ID          uint       `gorm:"primaryKey" json:"id"`
+	ChatID      int64      `gorm:"index;not null" json:"chat_id"`
+	ReporterID  int64      `gorm:"default:0" json:"reporter_id"`
+	RespondedBy int64      `gorm:"default:0;index" json:"responded_by"` // Первый админ, ответивший в чате после вызова
+	RespondedAt *time.Time `json:"responded_at,omitempty"`
+	CreatedAt   time.Time  `gorm:"index" json:"created_at"`
+}
+
+// UsernameHistory представляет запись истории username и имени пользователя в Postgres
+type UsernameHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    int64     `gorm:"index;not null" json:"user_id"`
+	Username  string    `gorm:"size:255" json:"username"`
+	FirstName string    `gorm:"size:255" json:"first_name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// UserNote представляет приватную заметку админа о пользователе в Postgres
+type UserNote struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    int64     `gorm:"index;not null" json:"user_id"`
+	AuthorID  int64     `gorm:"not null" json:"author_id"`
+	Text      string    `gorm:"type:text" json:"text"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ArchivedMessage представляет сообщение из архива чата в Postgres (только для чатов из ARCHIVE_CHATS)
+type ArchivedMessage struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	ChatID     int64     `gorm:"uniqueIndex:idx_archive_chat_message;not null" json:"chat_id"`
+	MessageID  int       `gorm:"uniqueIndex:idx_archive_chat_message;not null" json:"message_id"`
+	SenderID   int64     `gorm:"index" json:"sender_id"` // Пользователь или канал
+	SenderName string    `gorm:"size:255" json:"sender_name"`
+	Text       string    `gorm:"type:text" json:"text"` // Текст или подпись к медиа
+	ReplyToID  int       `gorm:"default:0" json:"reply_to_id"`
+	SentAt     time.Time `gorm:"index" json:"sent_at"`
+}
+
+// SpamDecision представляет решение антиспама по сообщению в Postgres
+type SpamDecision struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	ChatID    int64     `gorm:"not null" json:"chat_id"`
+	MessageID int       `json:"message_id"`
+	UserID    int64     `gorm:"index" json:"user_id"`
+	Score     int       `json:"score"`
+	Action    string    `gorm:"size:50;index" json:"action"`
+	Signals   string    `gorm:"size:500" json:"signals"` // Сработавшие сигналы: "urls:20, phrases:25"
+	DryRun    bool      `gorm:"default:false" json:"dry_run"`
+	Text      string    `gorm:"type:text" json:"text"`
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+// JoinRequest представляет заявку на вступление в чат с ответом на вопрос анкеты в Postgres
+type JoinRequest struct {
+	ID             uint       `gorm:"primaryKey" json:"id"`
+	ChatID         int64      `gorm:"not null" json:"chat_id"`
+	UserID         int64      `gorm:"index;not null" json:"user_id"`
+	FirstName      string     `gorm:"size:255" json:"first_name"`
+	Username       string     `gorm:"size:255" json:"username"`
+	Bio            string     `gorm:"type:text" json:"bio"`
+	Status         string     `gorm:"size:50;index" json:"status"` // asking, pending, approved, declined, expired
+	Answer         string     `gorm:"type:text" json:"answer"`
+	AdminMessageID int        `gorm:"default:0" json:"admin_message_id"` // Карточка заявки у главного админа
+	DecidedBy      int64      `gorm:"default:0" json:"decided_by"`       // 0 - решение принял бот
+	DecidedAt      *time.Time `gorm:"default:null" json:"decided_at,omitempty"`
+	CreatedAt      time.Time  `gorm:"index" json:"created_at"`
+}
+
+// ChatLockdown представляет закрытый админом чат и его права до закрытия в Postgres
+type ChatLockdown struct {
+	ChatID          int64      `gorm:"primaryKey" json:"chat_id"`
+	Mode            string     `gorm:"size:50" json:"mode"`                 // all - закрыт полностью, media - только текст
+	PrevPermissions string     `gorm:"type:text" json:"prev_permissions"`   // Права участников до закрытия в JSON
+	Until           *time.Time `gorm:"default:null" json:"until,omitempty"` // Когда открыть автоматически (nil - вручную)
+	StartedBy       int64      `json:"started_by"`
+	CreatedAt       time.Time  `json:"created_at"`
+}
+
+// SongAlias представляет дополнительный вариант названия песни для ответов на квиз в Postgres
+type SongAlias struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	SongName  string    `gorm:"size:500;index;not null" json:"song_name"` // Нормализованное название (textcases.NormalizeAnswer)
+	Alias     string    `gorm:"size:500;not null" json:"alias"`
+	AddedBy   int64     `json:"added_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
 func (User) TableName() string {
 	return "users"
 }
@@ -100,6 +292,10 @@ func (Admin) TableName() string {
 	return "admins"
 }
 
+func (Role) TableName() string {
+	return "admin_roles"
+}
+
 func (Audio) TableName() string {
 	return "audios"
 }
@@ -107,3 +303,63 @@ func (Audio) TableName() string {
 func (Horoscope) TableName() string {
 	return "horoscopes"
 }
+
+func (BlocklistEntry) TableName() string {
+	return "blocklist"
+}
+
+func (TempRole) TableName() string {
+	return "temp_roles"
+}
+
+func (ModerationAction) TableName() string {
+	return "moderation_actions"
+}
+
+func (AdminSuspension) TableName() string {
+	return "admin_suspensions"
+}
+
+func (AdminReport) TableName() string {
+	return "admin_reports"
+}
+
+func (UsernameHistory) TableName() string {
+	return "username_history"
+}
+
+func (UserNote) TableName() string {
+	return "user_notes"
+}
+
+func (ArchivedMessage) TableName() string {
+	return "archived_messages"
+}
+
+func (SpamDecision) TableName() string {
+	return "spam_decisions"
+}
+
+func (JoinRequest) TableName() string {
+	return "join_requests"
+}
+
+func (ChatLockdown) TableName() string {
+	return "chat_lockdowns"
+}
+
+func (SongAlias) TableName() string {
+	return "song_aliases"
+}
+
+func (QuizItem) TableName() string {
+	return "quiz_items"
+}
+
+func (QuizPoint) TableName() string {
+	return "quiz_points"
+}
+
+func (QuizSeasonResult) TableName() string {
+	return "quiz_season_results"
+}
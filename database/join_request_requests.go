@@ -0,0 +1,82 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Статусы заявок на вступление
+const (
+	JoinAsking   = "asking"   // Ждем ответа на вопрос анкеты
+	JoinPending  = "pending"  // Ждем решения админа
+	JoinApproved = "approved" // Заявка принята
+	JoinDeclined = "declined" // Заявка отклонена
+	JoinExpired  = "expired"  // Заявку никто не рассмотрел вовремя
+)
+
+// Создать заявку на вступление
+func (p *PostgresRepository) CreateJoinRequest(request *JoinRequest) error {
+	err := p.db.Create(request).Error
+	if err != nil {
+		return fmt.Errorf("failed to create join request of user %d: %w", request.UserID, err)
+	}
+	return nil
+}
+
+// Сохранить изменения заявки на вступление
+func (p *PostgresRepository) SaveJoinRequest(request *JoinRequest) error {
+	err := p.db.Save(request).Error
+	if err != nil {
+		return fmt.Errorf("failed to save join request %d: %w", request.ID, err)
+	}
+	return nil
+}
+
+// Получить заявку на вступление по ID
+func (p *PostgresRepository) GetJoinRequest(id uint) (*JoinRequest, error) {
+	var request JoinRequest
+	err := p.db.Where("id = ?", id).First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join request %d: %w", id, err)
+	}
+	return &request, nil
+}
+
+// Получить последнюю нерассмотренную заявку пользователя с указанным статусом (nil, если нет)
+func (p *PostgresRepository) GetOpenJoinRequest(userID int64, status string) (*JoinRequest, error) {
+	var request JoinRequest
+	err := p.db.Where("user_id = ? AND status = ?", userID, status).Order("created_at DESC").First(&request).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open join request of user %d: %w", userID, err)
+	}
+	return &request, nil
+}
+
+// Получить заявки с указанным статусом
+func (p *PostgresRepository) GetJoinRequestsByStatus(status string) ([]JoinRequest, error) {
+	var requests []JoinRequest
+	err := p.db.Where("status = ?", status).Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get join requests with status %s: %w", status, err)
+	}
+	return requests, nil
+}
+
+// Получить нерассмотренные заявки, поданные раньше указанного времени
+func (p *PostgresRepository) GetStaleJoinRequests(before time.Time) ([]JoinRequest, error) {
+	var requests []JoinRequest
+	err := p.db.Where("status IN ? AND created_at < ?", []string{JoinAsking, JoinPending}, before).Find(&requests).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get stale join requests: %w", err)
+	}
+	return requests, nil
+}
@@ -0,0 +1,50 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Сохранить закрытие чата
+func (p *PostgresRepository) SaveLockdown(lockdown *ChatLockdown) error {
+	err := p.db.Save(lockdown).Error
+	if err != nil {
+		return fmt.Errorf("failed to save lockdown of chat %d: %w", lockdown.ChatID, err)
+	}
+	return nil
+}
+
+// Получить закрытие чата (nil, если чат открыт)
+func (p *PostgresRepository) GetLockdown(chatID int64) (*ChatLockdown, error) {
+	var lockdown ChatLockdown
+	err := p.db.Where("chat_id = ?", chatID).First(&lockdown).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get lockdown of chat %d: %w", chatID, err)
+	}
+	return &lockdown, nil
+}
+
+// Удалить закрытие чата
+func (p *PostgresRepository) DeleteLockdown(chatID int64) error {
+	err := p.db.Where("chat_id = ?", chatID).Delete(&ChatLockdown{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete lockdown of chat %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// Получить закрытия чатов, срок которых истек
+func (p *PostgresRepository) GetExpiredLockdowns() ([]ChatLockdown, error) {
+	var lockdowns []ChatLockdown
+	err := p.db.Where("until IS NOT NULL AND until < ?", time.Now()).Find(&lockdowns).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired lockdowns: %w", err)
+	}
+	return lockdowns, nil
+}
@@ -0,0 +1,233 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Записать действие модерации в журнал
+func (p *PostgresRepository) SaveModerationAction(action *ModerationAction) error {
+	err := p.db.Create(action).Error
+	if err != nil {
+		return fmt.Errorf("failed to save moderation action %s of admin %d: %w", action.Action, action.AdminID, err)
+	}
+	return nil
+}
+
+// Посчитать неоткаченные действия админа с начала периода. Учитываются бессрочные действия
+// и действия длительностью не меньше minDuration минут, кроме учтенных при восстановлении прав
+func (p *PostgresRepository) CountAdminActions(adminID int64, actions []string, minDuration uint, since time.Time) (int64, error) {
+	var count int64
+	err := p.db.Model(&ModerationAction{}).
+		Where("admin_id = ? AND action IN ? AND reverted = ? AND acknowledged = ? AND created_at >= ?", adminID, actions, false, false, since).
+		Where("duration_minutes = 0 OR duration_minutes >= ?", minDuration).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count actions of admin %d: %w", adminID, err)
+	}
+	return count, nil
+}
+
+// Получить неоткаченные действия админа с начала периода
+func (p *PostgresRepository) GetRecentAdminActions(adminID int64, since time.Time) ([]ModerationAction, error) {
+	var actions []ModerationAction
+	err := p.db.Where("admin_id = ? AND reverted = ? AND created_at >= ?", adminID, false, since).
+		Order("created_at DESC").Find(&actions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get recent actions of admin %d: %w", adminID, err)
+	}
+	return actions, nil
+}
+
+// Отметить действие как откаченное
+func (p *PostgresRepository) MarkActionReverted(id uint) error {
+	err := p.db.Model(&ModerationAction{}).Where("id = ?", id).Update("reverted", true).Error
+	if err != nil {
+		return fmt.Errorf("failed to mark action %d as reverted: %w", id, err)
+	}
+	return nil
+}
+
+// Приостановить админские права пользователя. Снятые права при повторной приостановке не перезаписываются
+func (p *PostgresRepository) SuspendAdmin(userID int64, reason string, demotedRights string) error {
+	suspension := AdminSuspension{UserID: userID, Reason: reason, DemotedRights: demotedRights}
+	err := p.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"reason"}),
+	}).Create(&suspension).Error
+	if err != nil {
+		return fmt.Errorf("failed to suspend admin %d: %w", userID, err)
+	}
+	log.Printf("Admin %d suspended: %s", userID, reason)
+	return nil
+}
+
+// Получить приостановку админа, nil - права не приостановлены
+func (p *PostgresRepository) GetAdminSuspension(userID int64) (*AdminSuspension, error) {
+	var suspension AdminSuspension
+	err := p.db.Where("user_id = ?", userID).First(&suspension).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get suspension of admin %d: %w", userID, err)
+	}
+	return &suspension, nil
+}
+
+// Вернуть админские права пользователю. Действия до восстановления отмечаются учтенными,
+// чтобы они повторно не приостановили права
+func (p *PostgresRepository) LiftAdminSuspension(userID int64) error {
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&ModerationAction{}).
+			Where("admin_id = ? AND acknowledged = ?", userID, false).
+			Update("acknowledged", true).Error; err != nil {
+			return err
+		}
+		return tx.Delete(&AdminSuspension{}, "user_id = ?", userID).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to lift suspension of admin %d: %w", userID, err)
+	}
+	log.Printf("Admin %d suspension lifted", userID)
+	return nil
+}
+
+// Проверить, приостановлены ли админские права пользователя
+func (p *PostgresRepository) IsAdminSuspended(userID int64) bool {
+	var suspension AdminSuspension
+	err := p.db.Where("user_id = ?", userID).First(&suspension).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("failed to figure out if admin %d is suspended: %v", userID, err)
+	}
+	return err == nil
+}
+
+// Отметить последнее неоткаченное действие против пользователя как откаченное (например, после разбана)
+func (p *PostgresRepository) RevertLatestAction(targetID int64, actions []string) error {
+	var action ModerationAction
+	err := p.db.Where("target_id = ? AND action IN ? AND reverted = ?", targetID, actions, false).
+		Order("created_at DESC").First(&action).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get latest action against %d: %w", targetID, err)
+	}
+	return p.MarkActionReverted(action.ID)
+}
+
+// Сохранить вызов админов
+func (p *PostgresRepository) SaveAdminReport(report *AdminReport) error {
+	err := p.db.Create(report).Error
+	if err != nil {
+		return fmt.Errorf("failed to save admin report in chat %d: %w", report.ChatID, err)
+	}
+	return nil
+}
+
+// Отметить открытые вызовы админов в чате как отвеченные админом, если вызов был не раньше since.
+// Свой собственный вызов админ не закрывает
+func (p *PostgresRepository) RespondAdminReports(chatID int64, adminID int64, since time.Time) error {
+	err := p.db.Model(&AdminReport{}).
+		Where("chat_id = ? AND responded_at IS NULL AND created_at >= ? AND reporter_id <> ?", chatID, since, adminID).
+		Updates(map[string]any{"responded_by": adminID, "responded_at": time.Now()}).Error
+	if err != nil {
+		return fmt.Errorf("failed to respond admin reports in chat %d: %w", chatID, err)
+	}
+	return nil
+}
+
+// AdminStats содержит статистику модерации одного админа за период
+type AdminStats struct {
+	AdminID       int64
+	Warns         int
+	Mutes         int
+	Restricts     int
+	Bans          int
+	Kicks         int
+	MuteMinutes   uint // Суммарная длительность мутов, для среднего
+	Reverted      int
+	Reports       int           // Вызовы админов, на которые он ответил первым
+	ResponseTotal time.Duration // Суммарное время ответа на вызовы
+}
+
+// AvgMuteMinutes возвращает среднюю длительность мута
+func (s AdminStats) AvgMuteMinutes() float64 {
+	if s.Mutes == 0 {
+		return 0
+	}
+	return float64(s.MuteMinutes) / float64(s.Mutes)
+}
+
+// AvgResponse возвращает среднее время ответа на вызов админов
+func (s AdminStats) AvgResponse() time.Duration {
+	if s.Reports == 0 {
+		return 0
+	}
+	return s.ResponseTotal / time.Duration(s.Reports)
+}
+
+// Получить статистику модерации по админам с начала периода и количество вызовов без ответа
+func (p *PostgresRepository) GetAdminStats(since time.Time) ([]AdminStats, int, error) {
+	var actions []ModerationAction
+	err := p.db.Where("created_at >= ?", since).Find(&actions).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get moderation actions: %w", err)
+	}
+	var reports []AdminReport
+	err = p.db.Where("created_at >= ?", since).Find(&reports).Error
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to get admin reports: %w", err)
+	}
+
+	statsMap := make(map[int64]*AdminStats)
+	get := func(adminID int64) *AdminStats {
+		if _, ok := statsMap[adminID]; !ok {
+			statsMap[adminID] = &AdminStats{AdminID: adminID}
+		}
+		return statsMap[adminID]
+	}
+
+	for _, action := range actions {
+		stats := get(action.AdminID)
+		switch action.Action {
+		case "warn":
+			stats.Warns++
+		case "mute":
+			stats.Mutes++
+			stats.MuteMinutes += action.DurationMinutes
+		case "restrict":
+			stats.Restricts++
+		case "ban", "blocklist":
+			stats.Bans++
+		case "kick":
+			stats.Kicks++
+		}
+		if action.Reverted {
+			stats.Reverted++
+		}
+	}
+
+	unanswered := 0
+	for _, report := range reports {
+		if report.RespondedAt == nil || report.RespondedBy == 0 {
+			unanswered++
+			continue
+		}
+		stats := get(report.RespondedBy)
+		stats.Reports++
+		stats.ResponseTotal += report.RespondedAt.Sub(report.CreatedAt)
+	}
+
+	result := make([]AdminStats, 0, len(statsMap))
+	for _, stats := range statsMap {
+		result = append(result, *stats)
+	}
+	return result, unanswered, nil
+}
@@ -0,0 +1,43 @@
+package database
+
+import (
+	"fmt"
+	"log"
+)
+
+// Добавить заметку админа о пользователе
+func (p *PostgresRepository) AddUserNote(note *UserNote) error {
+	err := p.db.Create(note).Error
+	if err != nil {
+		return fmt.Errorf("failed to add note about user %d: %w", note.UserID, err)
+	}
+	log.Printf("Admin %d added a note about user %d", note.AuthorID, note.UserID)
+	return nil
+}
+
+// Получить заметки о пользователе, от старых к новым
+func (p *PostgresRepository) GetUserNotes(userID int64) ([]UserNote, error) {
+	var notes []UserNote
+	err := p.db.Where("user_id = ?", userID).Order("created_at ASC").Find(&notes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes about user %d: %w", userID, err)
+	}
+	return notes, nil
+}
+
+// Получить заметки о нескольких пользователях (userID -> заметки)
+func (p *PostgresRepository) GetUsersNotes(userIDs []int64) (map[int64][]UserNote, error) {
+	result := make(map[int64][]UserNote)
+	if len(userIDs) == 0 {
+		return result, nil
+	}
+	var notes []UserNote
+	err := p.db.Where("user_id IN ?", userIDs).Order("created_at ASC").Find(&notes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get notes about users: %w", err)
+	}
+	for _, note := range notes {
+		result[note.UserID] = append(result[note.UserID], note)
+	}
+	return result, nil
+}
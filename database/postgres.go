@@ -82,11 +82,39 @@ func AutoMigrate(db *gorm.DB) error {
 		&Admin{},
 		&Audio{},
 		&Horoscope{},
+		&BlocklistEntry{},
+		&Role{},
+		&TempRole{},
+		&ModerationAction{},
+		&AdminSuspension{},
+		&AdminReport{},
+		&UsernameHistory{},
+		&UserNote{},
+		&ArchivedMessage{},
+		&SpamDecision{},
+		&JoinRequest{},
+		&ChatLockdown{},
+		&SongAlias{},
+		&QuizItem{},
+		&QuizPoint{},
+		&QuizSeasonResult{},
 	)
 	if err != nil {
 		return fmt.Errorf("failed to migrate database: %w", err)
 	}
 
+	if err := migrateQuizKinds(db); err != nil {
+		return fmt.Errorf("failed to migrate quiz kinds: %w", err)
+	}
+
+	if err := migrateQuizPointSeasons(db); err != nil {
+		return fmt.Errorf("failed to migrate quiz point seasons: %w", err)
+	}
+
+	if err := seedDefaultRoles(db); err != nil {
+		return fmt.Errorf("failed to seed default admin roles: %w", err)
+	}
+
 	// GORM AutoMigrate в существующей БД иногда не создаёт новые таблицы — создаём audios явно
 	if !db.Migrator().HasTable(&Audio{}) {
 		log.Println("Creating audios table explicitly (was missing)...")
@@ -100,6 +128,39 @@ func AutoMigrate(db *gorm.DB) error {
 	return nil
 }
 
+// migrateQuizKinds переносит признак is_clip старых квизов в вид квиза и заполняет ответ названием песни
+func migrateQuizKinds(db *gorm.DB) error {
+	if db.Migrator().HasColumn(&Quiz{}, "is_clip") {
+		if err := db.Exec(`
+			UPDATE quizzes
+			SET kind = CASE WHEN is_clip THEN 'clip' ELSE 'quote' END
+			WHERE kind IS NULL OR kind = ''
+		`).Error; err != nil {
+			return fmt.Errorf("failed to fill quiz kinds: %w", err)
+		}
+		if err := db.Migrator().DropColumn(&Quiz{}, "is_clip"); err != nil {
+			return fmt.Errorf("failed to drop is_clip column: %w", err)
+		}
+		log.Println("Quiz is_clip flag migrated to kind")
+	}
+	if err := db.Exec(`UPDATE quizzes SET answer = song_name WHERE answer IS NULL OR answer = ''`).Error; err != nil {
+		return fmt.Errorf("failed to fill quiz answers: %w", err)
+	}
+	return nil
+}
+
+// migrateQuizPointSeasons относит очки, начисленные до появления сезонов, к месяцу начисления
+func migrateQuizPointSeasons(db *gorm.DB) error {
+	if err := db.Exec(`
+		UPDATE quiz_points
+		SET season = to_char(created_at AT TIME ZONE 'Europe/Moscow', 'YYYY-MM')
+		WHERE season IS NULL OR season = ''
+	`).Error; err != nil {
+		return fmt.Errorf("failed to fill quiz point seasons: %w", err)
+	}
+	return nil
+}
+
 // Получить статистику базы данных
 func (p *PostgresRepository) GetDatabaseStats() (map[string]interface{}, error) {
 	stats := make(map[string]interface{})
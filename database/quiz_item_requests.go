@@ -0,0 +1,90 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+)
+
+// Виды вопросов квиза
+const (
+	QuizItemQuote  = "quote"  // Цитата из песни
+	QuizItemScreen = "screen" // Кадр из клипа
+	QuizItemEmoji  = "emoji"  // Эмодзи-ребус, придуманный админом
+)
+
+// Добавить вопрос квиза
+func (p *PostgresRepository) AddQuizItem(item *QuizItem) error {
+	err := p.db.Create(item).Error
+	if err != nil {
+		return fmt.Errorf("failed to add quiz item for song %q: %w", item.SongName, err)
+	}
+	return nil
+}
+
+// Добавить несколько вопросов квиза одним запросом
+func (p *PostgresRepository) AddQuizItems(items []QuizItem) error {
+	if len(items) == 0 {
+		return nil
+	}
+	err := p.db.CreateInBatches(items, 100).Error
+	if err != nil {
+		return fmt.Errorf("failed to add %d quiz items: %w", len(items), err)
+	}
+	return nil
+}
+
+// Сохранить изменения вопроса квиза
+func (p *PostgresRepository) SaveQuizItem(item *QuizItem) error {
+	err := p.db.Save(item).Error
+	if err != nil {
+		return fmt.Errorf("failed to save quiz item %d: %w", item.ID, err)
+	}
+	return nil
+}
+
+// Получить вопрос квиза по ID (nil, если не найден)
+func (p *PostgresRepository) GetQuizItem(id uint) (*QuizItem, error) {
+	var item QuizItem
+	err := p.db.Where("id = ?", id).First(&item).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz item %d: %w", id, err)
+	}
+	return &item, nil
+}
+
+// Получить вопросы квиза указанного вида (все виды, если kind пустой)
+func (p *PostgresRepository) GetQuizItems(kind string) ([]QuizItem, error) {
+	var items []QuizItem
+	query := p.db.Order("id")
+	if kind != "" {
+		query = query.Where("kind = ?", kind)
+	}
+	if err := query.Find(&items).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quiz items: %w", err)
+	}
+	return items, nil
+}
+
+// Получить включенные вопросы квиза указанного вида
+func (p *PostgresRepository) GetEnabledQuizItems(kind string) ([]QuizItem, error) {
+	var items []QuizItem
+	err := p.db.Where("kind = ? AND enabled = ?", kind, true).Order("id").Find(&items).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get enabled quiz items of kind %s: %w", kind, err)
+	}
+	return items, nil
+}
+
+// Посчитать вопросы квиза
+func (p *PostgresRepository) CountQuizItems() (int64, error) {
+	var count int64
+	if err := p.db.Model(&QuizItem{}).Count(&count).Error; err != nil {
+		return 0, fmt.Errorf("failed to count quiz items: %w", err)
+	}
+	return count, nil
+}
@@ -0,0 +1,169 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Место пользователя в таблице очков квиза
+type QuizStanding struct {
+	UserID int64
+	Points int
+	Rank   int // Место с учетом равенства очков: при равных очках места одинаковые (1, 2, 2, 4)
+}
+
+// SeasonOf возвращает месячный сезон квиза, к которому относится момент времени
+func SeasonOf(t time.Time) string {
+	return t.In(MoscowTZ).Format("2006-01")
+}
+
+// Начислить очки за квиз. Если сезон не указан, очки идут в текущий
+func (p *PostgresRepository) AddQuizPoints(point *QuizPoint) error {
+	if point.Season == "" {
+		point.Season = SeasonOf(time.Now())
+	}
+	err := p.db.Create(point).Error
+	if err != nil {
+		return fmt.Errorf("failed to add quiz points for user %d: %w", point.UserID, err)
+	}
+	return nil
+}
+
+// Получить очки, начисленные за квиз, по местам
+func (p *PostgresRepository) GetQuizPoints(quizID uint) ([]QuizPoint, error) {
+	var points []QuizPoint
+	err := p.db.Where("quiz_id = ?", quizID).Order("rank ASC, created_at ASC").Find(&points).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get points of quiz %d: %w", quizID, err)
+	}
+	return points, nil
+}
+
+// Начислить очки за правильный ответ в квизе-опросе. Место считается внутри транзакции под блокировкой
+// строки квиза, поэтому параллельные ответы не получают одно место. pointsByRank - очки за первые места,
+// остальным по 1. Возвращает место, false - пользователь уже получил очки за этот опрос
+func (p *PostgresRepository) AddQuizPollPoints(quizID uint, userID int64, pointsByRank []int) (int, bool, error) {
+	rank := 0
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		var quiz Quiz
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").Where("id = ?", quizID).First(&quiz).Error; err != nil {
+			return err
+		}
+		var answered int64
+		if err := tx.Model(&QuizPoint{}).Where("quiz_id = ? AND user_id = ? AND reason = ?", quizID, userID, QuizKindPoll).Count(&answered).Error; err != nil {
+			return err
+		}
+		if answered > 0 {
+			return nil
+		}
+		var count int64
+		if err := tx.Model(&QuizPoint{}).Where("quiz_id = ? AND reason = ?", quizID, QuizKindPoll).Count(&count).Error; err != nil {
+			return err
+		}
+		rank = int(count) + 1
+		points := 1
+		if rank <= len(pointsByRank) {
+			points = pointsByRank[rank-1]
+		}
+		point := &QuizPoint{QuizID: quizID, UserID: userID, Points: points, Rank: rank, Reason: QuizKindPoll, Season: SeasonOf(time.Now())}
+		return tx.Create(point).Error
+	})
+	if err != nil {
+		return 0, false, fmt.Errorf("failed to add poll points of user %d for quiz %d: %w", userID, quizID, err)
+	}
+	return rank, rank > 0, nil
+}
+
+// Получить таблицу очков за сезон (пустой сезон - за все время), от лидера вниз. limit 0 - без ограничения.
+// При равных очках выше тот, кто раньше начал набирать очки, но место у них одно
+func (p *PostgresRepository) GetQuizStandings(season string, limit int) ([]QuizStanding, error) {
+	var standings []QuizStanding
+	query := p.db.Model(&QuizPoint{}).Select("user_id, SUM(points) AS points, RANK() OVER (ORDER BY SUM(points) DESC) AS rank")
+	if season != "" {
+		query = query.Where("season = ?", season)
+	}
+	query = query.Group("user_id").Order("points DESC, MIN(created_at) ASC")
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+	if err := query.Scan(&standings).Error; err != nil {
+		return nil, fmt.Errorf("failed to get quiz standings for season %q: %w", season, err)
+	}
+	return standings, nil
+}
+
+// Получить место и очки пользователя за сезон (пустой сезон - за все время). Место считается так же,
+// как в GetQuizStandings: на единицу больше числа игроков, у которых очков больше. Место 0 - очков нет
+func (p *PostgresRepository) GetQuizStanding(season string, userID int64) (int, int, error) {
+	userPoints := p.db.Model(&QuizPoint{}).Select("COALESCE(SUM(points), 0)").Where("user_id = ?", userID)
+	if season != "" {
+		userPoints = userPoints.Where("season = ?", season)
+	}
+	var points int
+	if err := userPoints.Scan(&points).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get quiz points of user %d: %w", userID, err)
+	}
+	if points == 0 {
+		return 0, 0, nil
+	}
+
+	ahead := p.db.Model(&QuizPoint{}).Select("user_id")
+	if season != "" {
+		ahead = ahead.Where("season = ?", season)
+	}
+	ahead = ahead.Group("user_id").Having("SUM(points) > ?", points)
+	var count int64
+	if err := p.db.Table("(?) AS ahead", ahead).Count(&count).Error; err != nil {
+		return 0, 0, fmt.Errorf("failed to get quiz rank of user %d: %w", userID, err)
+	}
+	return int(count) + 1, points, nil
+}
+
+// Посчитать, сколько последних завершенных квизов подряд выиграл пользователь
+func (p *PostgresRepository) GetQuizWinStreak(userID int64) (int, error) {
+	var quizzes []Quiz
+	err := p.db.Where("is_active = ?", false).Order("date DESC").Limit(30).Find(&quizzes).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to get quiz win streak of user %d: %w", userID, err)
+	}
+	streak := 0
+	for _, quiz := range quizzes {
+		if quiz.WinnerID != userID {
+			break
+		}
+		streak++
+	}
+	return streak, nil
+}
+
+// Проверить, подведены ли итоги сезона
+func (p *PostgresRepository) HasQuizSeasonResults(season string) (bool, error) {
+	var result QuizSeasonResult
+	err := p.db.Where("season = ?", season).First(&result).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("failed to check results of season %s: %w", season, err)
+	}
+	return true, nil
+}
+
+// Сохранить итоговую таблицу сезона
+func (p *PostgresRepository) SaveQuizSeasonResults(results []QuizSeasonResult) error {
+	if len(results) == 0 {
+		return nil
+	}
+	// Итоги сохраняются целиком или никак, иначе HasQuizSeasonResults посчитает сезон подведенным
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		return tx.CreateInBatches(results, 100).Error
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save results of season %s: %w", results[0].Season, err)
+	}
+	return nil
+}
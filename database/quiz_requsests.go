@@ -9,9 +9,18 @@ import (
 	"gorm.io/gorm"
 )
 
-// Сохранить данные квиза на определенную дату
-func (p *PostgresRepository) SaveQuizData(quote, songName, screenPath string, isClip bool, quizTime time.Time) error {
-	quizTimeInMoscow := quizTime.In(MoscowTZ)
+// Виды квиза
+const (
+	QuizKindQuote  = "quote"  // Угадать песню по цитате
+	QuizKindClip   = "clip"   // Угадать песню по кадру из клипа
+	QuizKindPoll   = "poll"   // Опрос-викторина Telegram с вариантами ответа
+	QuizKindLyrics = "lyrics" // Продолжить строчку из песни
+	QuizKindEmoji  = "emoji"  // Угадать песню по эмодзи-ребусу
+)
+
+// Сохранить данные квиза на дату из QuizTime. ID сохраненной записи записывается в quiz.ID
+func (p *PostgresRepository) SaveQuizData(quiz *Quiz) error {
+	quizTimeInMoscow := quiz.QuizTime.In(MoscowTZ)
 	date := time.Date(quizTimeInMoscow.Year(), quizTimeInMoscow.Month(), quizTimeInMoscow.Day(), 0, 0, 0, 0, time.UTC)
 
 	var existingQuiz Quiz
@@ -21,31 +30,22 @@ func (p *PostgresRepository) SaveQuizData(quote, songName, screenPath string, is
 		return fmt.Errorf("failed to check existing quiz: %w", err)
 	}
 
-	if err == gorm.ErrRecordNotFound {
-		quiz := Quiz{
-			Date:       date,
-			Quote:      quote,
-			SongName:   songName,
-			QuizTime:   quizTimeInMoscow,
-			IsActive:   true,
-			IsClip:     isClip,
-			ScreenPath: screenPath,
-		}
+	quiz.Date = date
+	quiz.QuizTime = quizTimeInMoscow
+	quiz.IsActive = true
 
-		err = p.db.Create(&quiz).Error
+	if err == gorm.ErrRecordNotFound {
+		quiz.ID = 0
+		err = p.db.Create(quiz).Error
 		if err != nil {
 			return fmt.Errorf("failed to create quiz: %w", err)
 		}
 
 		log.Printf("Created new quiz for date %s", date.Format("2006-01-02"))
 	} else {
-		existingQuiz.Quote = quote
-		existingQuiz.SongName = songName
-		existingQuiz.QuizTime = quizTimeInMoscow
-		existingQuiz.IsActive = true
-		existingQuiz.IsClip = isClip
-		existingQuiz.ScreenPath = screenPath
-		err = p.db.Save(&existingQuiz).Error
+		quiz.ID = existingQuiz.ID
+		quiz.CreatedAt = existingQuiz.CreatedAt
+		err = p.db.Save(quiz).Error
 		if err != nil {
 			return fmt.Errorf("failed to update quiz: %w", err)
 		}
@@ -56,6 +56,35 @@ func (p *PostgresRepository) SaveQuizData(quote, songName, screenPath string, is
 	return nil
 }
 
+// Запомнить отправленный опрос квиза
+func (p *PostgresRepository) SetQuizPoll(quizID uint, pollID string, messageID int, correct int) error {
+	result := p.db.Model(&Quiz{}).Where("id = ?", quizID).Updates(map[string]interface{}{
+		"poll_id":         pollID,
+		"poll_message_id": messageID,
+		"poll_correct":    correct,
+	})
+	if result.Error != nil {
+		return fmt.Errorf("failed to set poll for quiz %d: %w", quizID, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("quiz with id %d not found", quizID)
+	}
+	return nil
+}
+
+// Получить квиз по ID опроса Telegram
+func (p *PostgresRepository) GetQuizByPollID(pollID string) (*Quiz, error) {
+	var quiz Quiz
+	err := p.db.Where("poll_id = ?", pollID).First(&quiz).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz by poll %s: %w", pollID, err)
+	}
+	return &quiz, nil
+}
+
 // Получить данные квиза на сегодня
 func (p *PostgresRepository) LoadQuizData() (*Quiz, error) {
 	today := time.Now().In(MoscowTZ)
@@ -227,3 +256,24 @@ func (p *PostgresRepository) GetQuizWinnerID() (int64, error) {
 	}
 	return lastQuiz.WinnerID, nil
 }
+
+// Посчитать победы пользователя в квизах
+func (p *PostgresRepository) CountQuizWins(userID int64) (int64, error) {
+	var count int64
+	err := p.db.Model(&Quiz{}).Where("winner_id = ?", userID).Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("failed to count quiz wins of user %d: %w", userID, err)
+	}
+	return count, nil
+}
+
+// Получить историю квизов начиная с since для ротации вопросов: дата, вопрос и песня, от новых к старым
+func (p *PostgresRepository) GetQuizHistory(since time.Time) ([]Quiz, error) {
+	var quizzes []Quiz
+	err := p.db.Select("id", "date", "item_id", "quote", "screen_path", "file_id", "song_name", "kind").
+		Where("date >= ?", since).Order("date DESC").Find(&quizzes).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get quiz history: %w", err)
+	}
+	return quizzes, nil
+}
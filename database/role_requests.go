@@ -0,0 +1,149 @@
+package database
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Разрешения админских команд
+const (
+	PermWarn          = "warn"           // предупреждения, "извинись", "осуждаю"
+	PermMute          = "mute"           // мут и размут
+	PermRestrict      = "restrict"       // рестрикт, карантин новичков, закрытие чата
+	PermBan           = "ban"            // бан, разбан, блоклист
+	PermKick          = "kick"           // кик
+	PermManageQuiz    = "manage_quiz"    // информация и управление квизом
+	PermBroadcast     = "broadcast"      // сообщения на весь чат
+	PermManageCatalog = "manage_catalog" // загрузка треков в каталог
+)
+
+// AllPermissions содержит все известные разрешения
+var AllPermissions = []string{
+	PermWarn,
+	PermMute,
+	PermRestrict,
+	PermBan,
+	PermKick,
+	PermManageQuiz,
+	PermBroadcast,
+	PermManageCatalog,
+}
+
+// Роли по умолчанию повторяют прежнее деление на junior и senior
+var defaultRoles = []Role{
+	{Name: "junior", Rank: 1, Permissions: strings.Join([]string{PermWarn, PermMute, PermRestrict, PermBroadcast}, ",")},
+	{Name: "senior", Rank: 2, Permissions: strings.Join(AllPermissions, ",")},
+}
+
+// Создать роли по умолчанию, если таблица ролей пуста
+func seedDefaultRoles(db *gorm.DB) error {
+	var count int64
+	if err := db.Model(&Role{}).Count(&count).Error; err != nil {
+		return fmt.Errorf("failed to count roles: %w", err)
+	}
+	if count > 0 {
+		return nil
+	}
+	for _, role := range defaultRoles {
+		if err := db.Create(&role).Error; err != nil {
+			return fmt.Errorf("failed to create role %s: %w", role.Name, err)
+		}
+	}
+	log.Printf("Created default admin roles")
+	return nil
+}
+
+// PermissionList возвращает разрешения роли списком
+func (r Role) PermissionList() []string {
+	var perms []string
+	for perm := range strings.SplitSeq(r.Permissions, ",") {
+		perm = strings.TrimSpace(perm)
+		if perm != "" {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
+// HasPermission проверяет, есть ли у роли разрешение
+func (r Role) HasPermission(perm string) bool {
+	return slices.Contains(r.PermissionList(), perm)
+}
+
+// IsKnownPermission проверяет, существует ли такое разрешение
+func IsKnownPermission(perm string) bool {
+	return slices.Contains(AllPermissions, perm)
+}
+
+// Получить все роли, отсортированные по рангу
+func (p *PostgresRepository) GetRoles() ([]Role, error) {
+	var roles []Role
+	err := p.db.Order("rank ASC").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Получить роль по имени (nil, если роли нет)
+func (p *PostgresRepository) GetRole(name string) (*Role, error) {
+	var role Role
+	err := p.db.Where("name = ?", name).First(&role).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get role %s: %w", name, err)
+	}
+	return &role, nil
+}
+
+// Сохранить роль
+func (p *PostgresRepository) SaveRole(role *Role) error {
+	for _, perm := range role.PermissionList() {
+		if !IsKnownPermission(perm) {
+			return fmt.Errorf("unknown permission %q", perm)
+		}
+	}
+	err := p.db.Save(role).Error
+	if err != nil {
+		return fmt.Errorf("failed to save role %s: %w", role.Name, err)
+	}
+	return nil
+}
+
+// Получить разрешения админа (пустой список, если пользователь не админ)
+func (p *PostgresRepository) GetAdminPermissions(userID int64) ([]string, error) {
+	var admin Admin
+	err := p.db.Where("id = ?", userID).First(&admin).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get admin %d: %w", userID, err)
+	}
+	role, err := p.GetRole(admin.AdminRole)
+	if err != nil {
+		return nil, err
+	}
+	if role == nil {
+		log.Printf("admin %d has unknown role %s, no permissions granted", userID, admin.AdminRole)
+		return nil, nil
+	}
+	return role.PermissionList(), nil
+}
+
+// Проверить, есть ли у админа разрешение
+func (p *PostgresRepository) AdminHasPermission(userID int64, perm string) bool {
+	perms, err := p.GetAdminPermissions(userID)
+	if err != nil {
+		log.Printf("failed to get permissions of admin %d: %v", userID, err)
+		return false
+	}
+	return slices.Contains(perms, perm)
+}
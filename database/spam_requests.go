@@ -0,0 +1,34 @@
+package database
+
+import (
+	"fmt"
+	"time"
+)
+
+// Записать решение антиспама
+func (p *PostgresRepository) SaveSpamDecision(decision *SpamDecision) error {
+	err := p.db.Create(decision).Error
+	if err != nil {
+		return fmt.Errorf("failed to save spam decision for message %d: %w", decision.MessageID, err)
+	}
+	return nil
+}
+
+// Получить последние решения антиспама, от новых к старым
+func (p *PostgresRepository) GetRecentSpamDecisions(limit int) ([]SpamDecision, error) {
+	var decisions []SpamDecision
+	err := p.db.Order("created_at DESC").Limit(limit).Find(&decisions).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get spam decisions: %w", err)
+	}
+	return decisions, nil
+}
+
+// Удалить решения антиспама старше before, возвращает количество удаленных
+func (p *PostgresRepository) PurgeSpamDecisions(before time.Time) (int64, error) {
+	result := p.db.Where("created_at < ?", before).Delete(&SpamDecision{})
+	if result.Error != nil {
+		return 0, fmt.Errorf("failed to purge spam decisions: %w", result.Error)
+	}
+	return result.RowsAffected, nil
+}
@@ -0,0 +1,114 @@
+package database
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Kind временной роли победителя квиза: роль живет до следующего квиза
+const TempRoleQuizWinner = "quiz_winner"
+
+// PermissionList возвращает разрешения временной роли списком
+func (r TempRole) PermissionList() []string {
+	return Role{Permissions: r.Permissions}.PermissionList()
+}
+
+// Сохранить временную роль. Роль того же назначения у пользователя заменяется
+func (p *PostgresRepository) SaveTempRole(role *TempRole) error {
+	for _, perm := range role.PermissionList() {
+		if !IsKnownPermission(perm) {
+			return fmt.Errorf("unknown permission %q", perm)
+		}
+	}
+	role.Permissions = strings.Join(role.PermissionList(), ",")
+	err := p.db.Where("user_id = ? AND kind = ?", role.UserID, role.Kind).Delete(&TempRole{}).Error
+	if err != nil {
+		return fmt.Errorf("failed to replace temp role %s of user %d: %w", role.Kind, role.UserID, err)
+	}
+	err = p.db.Create(role).Error
+	if err != nil {
+		return fmt.Errorf("failed to save temp role %s of user %d: %w", role.Kind, role.UserID, err)
+	}
+	log.Printf("Temp role %s granted to user %d", role.Kind, role.UserID)
+	return nil
+}
+
+// Получить действующие временные роли пользователя
+func (p *PostgresRepository) GetActiveTempRoles(userID int64) ([]TempRole, error) {
+	var roles []TempRole
+	err := p.db.Where("user_id = ? AND (expires_at IS NULL OR expires_at > ?)", userID, time.Now()).
+		Order("created_at ASC").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get temp roles of user %d: %w", userID, err)
+	}
+	return roles, nil
+}
+
+// Получить все временные роли, отсортированные по сроку действия
+func (p *PostgresRepository) GetAllTempRoles() ([]TempRole, error) {
+	var roles []TempRole
+	err := p.db.Order("expires_at ASC NULLS LAST").Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get temp roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Получить временные роли с истекшим сроком действия
+func (p *PostgresRepository) GetExpiredTempRoles() ([]TempRole, error) {
+	var roles []TempRole
+	err := p.db.Where("expires_at IS NOT NULL AND expires_at <= ?", time.Now()).Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expired temp roles: %w", err)
+	}
+	return roles, nil
+}
+
+// Получить временные роли по назначению
+func (p *PostgresRepository) GetTempRolesByKind(kind string) ([]TempRole, error) {
+	var roles []TempRole
+	err := p.db.Where("kind = ?", kind).Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get temp roles of kind %s: %w", kind, err)
+	}
+	return roles, nil
+}
+
+// Получить временные роли пользователя (в том числе истекшие, но еще не снятые)
+func (p *PostgresRepository) GetUserTempRoles(userID int64) ([]TempRole, error) {
+	var roles []TempRole
+	err := p.db.Where("user_id = ?", userID).Find(&roles).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get temp roles of user %d: %w", userID, err)
+	}
+	return roles, nil
+}
+
+// Удалить временную роль
+func (p *PostgresRepository) DeleteTempRole(id uint) error {
+	err := p.db.Delete(&TempRole{}, id).Error
+	if err != nil {
+		return fmt.Errorf("failed to delete temp role %d: %w", id, err)
+	}
+	return nil
+}
+
+// Получить разрешения пользователя из всех действующих временных ролей
+func (p *PostgresRepository) GetTempRolePermissions(userID int64) ([]string, error) {
+	roles, err := p.GetActiveTempRoles(userID)
+	if err != nil {
+		return nil, err
+	}
+	var perms []string
+	for _, role := range roles {
+		for _, perm := range role.PermissionList() {
+			if !slices.Contains(perms, perm) {
+				perms = append(perms, perm)
+			}
+		}
+	}
+	return perms, nil
+}
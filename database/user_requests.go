@@ -1,6 +1,7 @@
 package database
 
 import (
+	"errors"
 	"fmt"
 	"log"
 	"time"
@@ -43,10 +44,23 @@ func (p *PostgresRepository) GetUser(userID int64) (User, error) {
 	return user, nil
 }
 
-// Получить пользователя по username
+// Найти пользователя по Telegram UserID без создания, nil - пользователя нет в базе
+func (p *PostgresRepository) FindUser(userID int64) (*User, error) {
+	var user User
+	err := p.db.Where("user_id = ?", userID).First(&user).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to find user %d: %w", userID, err)
+	}
+	return &user, nil
+}
+
+// Получить пользователя по username (без учета регистра)
 func (p *PostgresRepository) GetUserByUsername(username string) (*User, error) {
 	var user User
-	err := p.db.Where("username = ?", username).First(&user).Error
+	err := p.db.Where("LOWER(username) = LOWER(?)", username).First(&user).Error
 
 	if err != nil {
 		if err == gorm.ErrRecordNotFound {
@@ -101,6 +115,57 @@ func (p *PostgresRepository) UpdateUsername(userID int64, username string) error
 	return nil
 }
 
+// Записать смену username или имени пользователя в историю. Если истории у пользователя еще нет,
+// сначала записывается прежнее имя, чтобы по истории было видно, как его звали до первой смены
+func (p *PostgresRepository) RecordUsernameChange(userID int64, oldUsername, oldFirstName, username, firstName string) {
+	if oldUsername == username && oldFirstName == firstName {
+		return
+	}
+	err := p.db.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&UsernameHistory{}).Where("user_id = ?", userID).Count(&count).Error; err != nil {
+			return err
+		}
+		if count == 0 && (oldUsername != "" || oldFirstName != "") {
+			previous := UsernameHistory{UserID: userID, Username: oldUsername, FirstName: oldFirstName}
+			if err := tx.Create(&previous).Error; err != nil {
+				return err
+			}
+		}
+		if username == "" && firstName == "" {
+			return nil
+		}
+		entry := UsernameHistory{UserID: userID, Username: username, FirstName: firstName}
+		return tx.Create(&entry).Error
+	})
+	if err != nil {
+		log.Printf("failed to record username change of user %d: %v", userID, err)
+	}
+}
+
+// Получить историю username и имени пользователя, от старых к новым
+func (p *PostgresRepository) GetUsernameHistory(userID int64) ([]UsernameHistory, error) {
+	var history []UsernameHistory
+	err := p.db.Where("user_id = ?", userID).Order("created_at ASC, id ASC").Find(&history).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get username history of user %d: %w", userID, err)
+	}
+	return history, nil
+}
+
+// Найти пользователя по username из истории (0, если такой username не встречался)
+func (p *PostgresRepository) GetUserIDByPastUsername(username string) (int64, error) {
+	var entry UsernameHistory
+	err := p.db.Where("LOWER(username) = LOWER(?)", username).Order("created_at DESC, id DESC").First(&entry).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to find user by past username %s: %w", username, err)
+	}
+	return entry.UserID, nil
+}
+
 // Обновить админский статус пользователя на основе переменной окружения ADMINS
 func (p *PostgresRepository) IsUserAdmin(user *User) bool {
 	admins := environment.GetAdmins()
@@ -112,7 +177,8 @@ func (p *PostgresRepository) IsUserAdmin(user *User) bool {
 	return newAdminStatus
 }
 
-// Обновить админский статус для всех пользователей
+// Добавить в админы пользователей из переменной окружения ADMINS.
+// Снятие админки выполняется только командой /revoke, чтобы не терять роли, выданные через /grant
 func (p *PostgresRepository) RefreshAllUsersAdminStatus() error {
 	log.Printf("Starting admin status refresh for all users...")
 
@@ -134,12 +200,10 @@ func (p *PostgresRepository) RefreshAllUsersAdminStatus() error {
 		_, isAdminInDB := adminMap[user.UserID]
 		isAdminInConfig := p.IsUserAdmin(&user)
 
+		err = nil
 		if isAdminInConfig && !isAdminInDB {
 			err = p.SaveAdmin(user, "junior")
 			updatedCount++
-		} else if !isAdminInConfig && isAdminInDB {
-			err = p.RemoveAdmin(user.UserID)
-			updatedCount++
 		}
 
 		if err != nil {
@@ -230,25 +294,44 @@ func (p *PostgresRepository) RemoveAdmin(userID int64) error {
 	return nil
 }
 
-// Продвинуть админа
-func (p *PostgresRepository) PromoteAdmin(userID int64, delta string) error {
+// Продвинуть админа на соседнюю роль по рангу: "+" - выше, "-" - ниже. Возвращает новую роль
+func (p *PostgresRepository) PromoteAdmin(userID int64, delta string) (string, error) {
 	var admin Admin
-	err := p.db.Where("id = ?", userID).First(&admin).Error
+	err := p.db.Preload("User").Where("id = ?", userID).First(&admin).Error
 	if err != nil {
-		return fmt.Errorf("failed to get %d from admins: %w", userID, err)
+		return "", fmt.Errorf("failed to get %d from admins: %w", userID, err)
 	}
-	if delta == "+" && admin.AdminRole == "junior" {
-		admin.AdminRole = "senior"
-	} else if delta == "-" && admin.AdminRole == "senior" {
-		admin.AdminRole = "junior"
-	} else {
-		return fmt.Errorf("delta %s can't be used to change role %s", delta, admin.AdminRole)
+	current, err := p.GetRole(admin.AdminRole)
+	if err != nil {
+		return "", err
+	}
+	if current == nil {
+		return "", fmt.Errorf("admin %d has unknown role %s", userID, admin.AdminRole)
+	}
+
+	var next Role
+	query := p.db.Model(&Role{})
+	switch delta {
+	case "+":
+		query = query.Where("rank > ?", current.Rank).Order("rank ASC")
+	case "-":
+		query = query.Where("rank < ?", current.Rank).Order("rank DESC")
+	default:
+		return "", fmt.Errorf("unknown delta %s", delta)
+	}
+	err = query.First(&next).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return "", fmt.Errorf("delta %s can't be used to change role %s", delta, admin.AdminRole)
 	}
-	err = p.SaveAdmin(admin.User, admin.AdminRole)
 	if err != nil {
-		return err
+		return "", fmt.Errorf("failed to find next role for %s: %w", admin.AdminRole, err)
 	}
-	return nil
+
+	err = p.SaveAdmin(admin.User, next.Name)
+	if err != nil {
+		return "", err
+	}
+	return next.Name, nil
 }
 
 // Обновить количество сообщений пользователя
@@ -391,7 +474,8 @@ func (p *PostgresRepository) GetAllMutedToUnmute() ([]User, error) {
 	query := p.db.Where(
 		`status = 'muted'
 		AND muted_until IS NOT NULL
-		AND muted_until < ?`,
+		AND muted_until < ?
+		AND left_chat = false`,
 		now,
 	)
 
@@ -403,6 +487,57 @@ func (p *PostgresRepository) GetAllMutedToUnmute() ([]User, error) {
 	return users, nil
 }
 
+// Получить всех пользователей, у которых истек карантин новичка
+func (p *PostgresRepository) GetQuarantinesToLift() ([]User, error) {
+	var users []User
+	err := p.db.Where(
+		`quarantined = true
+		AND quarantine_until IS NOT NULL
+		AND quarantine_until < ?
+		AND left_chat = false`,
+		time.Now().In(MoscowTZ),
+	).Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users to release from quarantine: %w", err)
+	}
+	return users, nil
+}
+
+// Получить всех пользователей, с которых пора снять рестрикт
+func (p *PostgresRepository) GetAllRestrictedToLift() ([]User, error) {
+	var users []User
+	err := p.db.Where(
+		`status = 'restricted'
+		AND restricted_until IS NOT NULL
+		AND restricted_until < ?
+		AND left_chat = false`,
+		time.Now().In(MoscowTZ),
+	).Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users to unrestrict: %w", err)
+	}
+	return users, nil
+}
+
+// Получить пользователей для сверки статуса с Telegram (кроме вышедших из чата, проходящих проверку и задержанных при входе)
+func (p *PostgresRepository) GetUsersToReconcile() ([]User, error) {
+	var users []User
+	err := p.db.Where("left_chat = false AND status NOT IN ?", []string{"new_user", "held"}).Find(&users).Error
+	if err != nil {
+		return nil, fmt.Errorf("failed to get users to reconcile: %w", err)
+	}
+	return users, nil
+}
+
+// Отметить, что пользователь вышел из чата или вернулся в него
+func (p *PostgresRepository) SetUserLeftChat(userID int64, left bool) error {
+	err := p.db.Model(&User{}).Where("user_id = ?", userID).Update("left_chat", left).Error
+	if err != nil {
+		return fmt.Errorf("failed to set left_chat for user %d: %w", userID, err)
+	}
+	return nil
+}
+
 func (p *PostgresRepository) GetAllMutedUsers() ([]User, error) {
 	var users []User
 	err := p.db.Where(`status = ?`, "muted").Find(&users).Error
@@ -5,6 +5,7 @@ import (
 	"os"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type MainEnvironment struct {
@@ -16,6 +17,75 @@ type MainEnvironment struct {
 	AdminsUsernames     []string
 	QuizChatID          int64
 	HoroscopChannelLink string
+	AdminSyncInterval   time.Duration // Период сверки админов с администраторами чатов
+	ReconcileInterval   time.Duration // Период сверки наказаний в базе с правами в Telegram
+	MessageIndexSize    int           // Сколько последних сообщений каждого чата помнить для зачистки
+}
+
+// Лимиты на разрушительные действия одного админа за окно времени (0 - без лимита)
+type LimitsEnvironment struct {
+	Window          time.Duration // Окно подсчета действий
+	MaxBans         int           // Баны, включая блоклист
+	MaxKicks        int           // Кики
+	MaxLongMutes    int           // Рестрикты и муты не короче LongMuteMinutes
+	LongMuteMinutes uint          // С какой длительности мут считается в лимите
+	RevertWindow    time.Duration // За какой период откатываются действия приостановленного админа
+}
+
+// Голосования участников за мут и бан
+type VoteEnvironment struct {
+	MuteQuorum  int           // Сколько голосов "за" нужно для мута
+	BanQuorum   int           // Сколько голосов "за" нужно для бана (0 - голосование за бан выключено)
+	Window      time.Duration // Сколько длится голосование
+	MuteMinutes uint          // На сколько мутить по итогам голосования
+	MinAge      time.Duration // Сколько времени участник должен быть в базе, чтобы голосовать
+	MinMessages int           // Сколько сообщений участник должен написать, чтобы голосовать
+}
+
+// Антиспам: пороги баллов для действий (0 - действие выключено)
+type SpamEnvironment struct {
+	DeleteScore      int           // С какого балла сообщение молча удаляется
+	MuteScore        int           // С какого балла сообщение удаляется, а автор получает мут
+	BanScore         int           // С какого балла автор банится
+	MuteMinutes      uint          // На сколько мутить спамера
+	NewcomerMessages int           // Сколько сообщений нужно написать, чтобы перестать считаться новичком
+	DryRun           bool          // Только записывать решения и присылать их главному админу
+	Phrases          []string      // Дополнительные спам-фразы
+	LogRetention     time.Duration // Сколько хранить решения антиспама в базе
+}
+
+// Проверка профилей при входе в чат
+type ScreenEnvironment struct {
+	Action         string   // hold - замутить до решения админа, kick - кикнуть, off - не проверять
+	Patterns       []string // Дополнительные регулярные выражения для имен
+	ProtectedNames []string // Имена, под которые нельзя маскироваться, помимо админов
+}
+
+// Карантин новичков после проверки "Я не бот!" (0 в обоих полях - карантин выключен)
+type QuarantineEnvironment struct {
+	Hours    int // Через сколько часов карантин снимается (0 - не снимать по времени)
+	Messages int // После скольких сообщений карантин снимается (0 - не снимать по сообщениям)
+}
+
+// Заявки на вступление в чат
+type JoinEnvironment struct {
+	AutoApprove bool          // Принимать заявку сразу после правильного ответа на вопрос анкеты
+	Timeout     time.Duration // Через сколько нерассмотренная заявка отклоняется
+}
+
+// Подсказки и срок ответа на квиз
+type QuizEnvironment struct {
+	HintAfter    []time.Duration // Через сколько после начала квиза давать очередную подсказку
+	Deadline     time.Duration   // Через сколько без ответа бот раскрывает песню (0 - ждать до победителя)
+	PollDuration time.Duration   // Сколько открыт опрос квиза-опроса
+	RepeatAfter  time.Duration   // Через сколько вопрос можно загадать снова
+	SongRepeat   time.Duration   // Через сколько песня может снова стать ответом
+}
+
+// Архив сообщений чатов (выключен, если список чатов пуст)
+type ArchiveEnvironment struct {
+	Chats     []int64       // Чаты, сообщения которых сохраняются в архив
+	Retention time.Duration // Сколько хранить сообщения в архиве
 }
 
 type PostgreSQLEnvironment struct {
@@ -45,6 +115,11 @@ func GetMainEnvironment() MainEnvironment {
 	mainAdminID := getMainAdminID()
 	// katyaID := getKatyaID()
 	horoscopChannelLink := getHoroscopChannelLink()
+	adminSyncInterval := getAdminSyncInterval()
+	reconcileInterval := time.Duration(getIntEnv("RECONCILE_HOURS", 6)) * time.Hour
+	if reconcileInterval == 0 {
+		reconcileInterval = 6 * time.Hour
+	}
 
 	return MainEnvironment{
 		Token:           os.Getenv("BOT_TOKEN"),
@@ -55,6 +130,9 @@ func GetMainEnvironment() MainEnvironment {
 		MainAdminID:     mainAdminID,
 		// KatyaID:             katyaID,
 		HoroscopChannelLink: horoscopChannelLink,
+		AdminSyncInterval:   adminSyncInterval,
+		ReconcileInterval:   reconcileInterval,
+		MessageIndexSize:    getIntEnv("MESSAGE_INDEX_SIZE", 2000),
 	}
 }
 
@@ -69,6 +147,127 @@ func GetDataEnvironment() DataEnvironment {
 	}
 }
 
+func GetLimitsEnvironment() LimitsEnvironment {
+	return LimitsEnvironment{
+		Window:          time.Duration(getIntEnv("ADMIN_LIMIT_WINDOW_MINUTES", 60)) * time.Minute,
+		MaxBans:         getIntEnv("ADMIN_LIMIT_BANS", 5),
+		MaxKicks:        getIntEnv("ADMIN_LIMIT_KICKS", 10),
+		MaxLongMutes:    getIntEnv("ADMIN_LIMIT_LONG_MUTES", 10),
+		LongMuteMinutes: uint(getIntEnv("ADMIN_LIMIT_LONG_MUTE_MINUTES", 60)),
+		RevertWindow:    time.Duration(getIntEnv("ADMIN_LIMIT_REVERT_HOURS", 24)) * time.Hour,
+	}
+}
+
+func GetVoteEnvironment() VoteEnvironment {
+	return VoteEnvironment{
+		MuteQuorum:  getIntEnv("VOTE_MUTE_QUORUM", 5),
+		BanQuorum:   getIntEnv("VOTE_BAN_QUORUM", 0),
+		Window:      time.Duration(getIntEnv("VOTE_WINDOW_MINUTES", 10)) * time.Minute,
+		MuteMinutes: uint(getIntEnv("VOTE_MUTE_MINUTES", 30)),
+		MinAge:      time.Duration(getIntEnv("VOTE_MIN_AGE_DAYS", 7)) * 24 * time.Hour,
+		MinMessages: getIntEnv("VOTE_MIN_MESSAGES", 20),
+	}
+}
+
+func GetSpamEnvironment() SpamEnvironment {
+	var phrases []string
+	for phrase := range strings.SplitSeq(os.Getenv("SPAM_PHRASES"), ",") {
+		if phrase = strings.TrimSpace(phrase); phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	logRetentionDays := getIntEnv("SPAM_LOG_RETENTION_DAYS", 30)
+	if logRetentionDays == 0 {
+		logRetentionDays = 30
+	}
+	return SpamEnvironment{
+		DeleteScore:      getIntEnv("SPAM_DELETE_SCORE", 50),
+		MuteScore:        getIntEnv("SPAM_MUTE_SCORE", 70),
+		BanScore:         getIntEnv("SPAM_BAN_SCORE", 100),
+		MuteMinutes:      uint(getIntEnv("SPAM_MUTE_MINUTES", 60)),
+		NewcomerMessages: getIntEnv("SPAM_NEWCOMER_MESSAGES", 10),
+		LogRetention:     time.Duration(logRetentionDays) * 24 * time.Hour,
+		// По умолчанию антиспам только присылает отчеты, пока пороги не настроены
+		DryRun:  strings.TrimSpace(strings.ToLower(os.Getenv("SPAM_DRY_RUN"))) != "false",
+		Phrases: phrases,
+	}
+}
+
+func GetScreenEnvironment() ScreenEnvironment {
+	action := strings.ToLower(strings.TrimSpace(os.Getenv("SCREEN_ACTION")))
+	switch action {
+	case "hold", "kick", "off":
+	case "":
+		action = "hold"
+	default:
+		log.Printf("Неизвестное значение SCREEN_ACTION %q, используем hold", action)
+		action = "hold"
+	}
+	return ScreenEnvironment{
+		Action: action,
+		// Регулярные выражения могут содержать запятые, поэтому разделитель - точка с запятой
+		Patterns:       strings.Split(os.Getenv("SCREEN_PATTERNS"), ";"),
+		ProtectedNames: strings.Split(os.Getenv("SCREEN_PROTECTED_NAMES"), ","),
+	}
+}
+
+func GetQuarantineEnvironment() QuarantineEnvironment {
+	return QuarantineEnvironment{
+		Hours:    getIntEnv("QUARANTINE_HOURS", 24),
+		Messages: getIntEnv("QUARANTINE_MESSAGES", 20),
+	}
+}
+
+func GetJoinEnvironment() JoinEnvironment {
+	return JoinEnvironment{
+		AutoApprove: strings.TrimSpace(strings.ToLower(os.Getenv("JOIN_AUTO_APPROVE"))) != "false",
+		Timeout:     time.Duration(getIntEnv("JOIN_REQUEST_TIMEOUT_MINUTES", 60)) * time.Minute,
+	}
+}
+
+func GetQuizEnvironment() QuizEnvironment {
+	var hintAfter []time.Duration
+	hints := os.Getenv("QUIZ_HINT_MINUTES")
+	if hints == "" {
+		hints = "10,20,30"
+	}
+	for _, value := range strings.Split(hints, ",") {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		minutes, err := strconv.Atoi(value)
+		if err != nil || minutes <= 0 {
+			log.Printf("Ошибка парсинга QUIZ_HINT_MINUTES: %q пропущено", value)
+			continue
+		}
+		hintAfter = append(hintAfter, time.Duration(minutes)*time.Minute)
+	}
+	// Опрос нельзя оставить открытым до победителя, поэтому 0 заменяется значением по умолчанию
+	pollMinutes := getIntEnv("QUIZ_POLL_MINUTES", 30)
+	if pollMinutes == 0 {
+		pollMinutes = 30
+	}
+	return QuizEnvironment{
+		HintAfter:    hintAfter,
+		Deadline:     time.Duration(getIntEnv("QUIZ_DEADLINE_MINUTES", 60)) * time.Minute,
+		PollDuration: time.Duration(pollMinutes) * time.Minute,
+		RepeatAfter:  time.Duration(getIntEnv("QUIZ_REPEAT_DAYS", 60)) * 24 * time.Hour,
+		SongRepeat:   time.Duration(getIntEnv("QUIZ_SONG_REPEAT_DAYS", 7)) * 24 * time.Hour,
+	}
+}
+
+func GetArchiveEnvironment() ArchiveEnvironment {
+	retentionDays := getIntEnv("ARCHIVE_RETENTION_DAYS", 30)
+	if retentionDays == 0 {
+		retentionDays = 30
+	}
+	return ArchiveEnvironment{
+		Chats:     getChatList("ARCHIVE_CHATS"),
+		Retention: time.Duration(retentionDays) * 24 * time.Hour,
+	}
+}
+
 func GetPostgreSQLEnvironment() PostgreSQLEnvironment {
 	portStr := os.Getenv("POSTGRES_PORT")
 	port, err := strconv.Atoi(portStr)
@@ -112,7 +311,12 @@ func GetPostgreSQLEnvironment() PostgreSQLEnvironment {
 }
 
 func getAllowedChats() []int64 {
-	allowedChats := os.Getenv("ALLOWED_CHATS")
+	return getChatList("ALLOWED_CHATS")
+}
+
+// getChatList возвращает список ID чатов через запятую из переменной окружения
+func getChatList(name string) []int64 {
+	allowedChats := os.Getenv(name)
 	allowedChatsSlice := strings.Split(allowedChats, ",")
 	var allowedChatsInts []int64
 	for i, s := range allowedChatsSlice {
@@ -213,3 +417,30 @@ func getHoroscopChannelLink() string {
 	}
 	return horoscopChannelLink
 }
+
+func getAdminSyncInterval() time.Duration {
+	minutes := os.Getenv("ADMIN_SYNC_MINUTES")
+	if minutes == "" {
+		return 30 * time.Minute // default value
+	}
+	minutesInt, err := strconv.Atoi(strings.TrimSpace(minutes))
+	if err != nil || minutesInt <= 0 {
+		log.Printf("Ошибка парсинга ADMIN_SYNC_MINUTES: %v, используем 30 минут", err)
+		return 30 * time.Minute
+	}
+	return time.Duration(minutesInt) * time.Minute
+}
+
+// getIntEnv возвращает неотрицательное число из переменной окружения или значение по умолчанию
+func getIntEnv(name string, defaultValue int) int {
+	value := os.Getenv(name)
+	if value == "" {
+		return defaultValue
+	}
+	valueInt, err := strconv.Atoi(strings.TrimSpace(value))
+	if err != nil || valueInt < 0 {
+		log.Printf("Ошибка парсинга %s: %v, используем %d", name, err, defaultValue)
+		return defaultValue
+	}
+	return valueInt
+}
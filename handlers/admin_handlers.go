@@ -2,7 +2,9 @@ package handlers
 
 import (
 	"fmt"
+	"log"
 	"saxbot/admins"
+	"saxbot/database"
 	"saxbot/messages"
 	"strconv"
 	"strings"
@@ -10,7 +12,7 @@ import (
 	tele "gopkg.in/telebot.v4"
 )
 
-func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandler, isWinnerOnly bool) error {
+func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandler, isTempRoleOnly bool) error {
 	chatMsg := chatMessageHandler.ChatMessage
 	if chatMsg == nil {
 		return fmt.Errorf("chat message is nil")
@@ -26,6 +28,12 @@ func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandl
 		return handleWarns(c, chatMessageHandler)
 	case "гороскоп", "/horoscope":
 		return handleHoroscope(c, chatMessageHandler)
+	case "топ квиза", "/quiztop":
+		return handleQuizTop(c, chatMessageHandler)
+	}
+
+	if kind, ok := isVoteCommand(text); ok {
+		return handleStartVote(c, chatMessageHandler, kind)
 	}
 
 	normalized := strings.ReplaceAll(text, ",", " ")
@@ -37,20 +45,16 @@ func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandl
 	// 	return messages.ReplyMessage(c, textcases.GetSerbMessage(), chatMsg.ThreadID())
 	// }
 
-	// Победитель (не админ) может использовать только "предупреждение" и "извинись"
-	if isWinnerOnly {
-		switch text {
-		case "предупреждение":
-			return handleWarn(c, chatMessageHandler)
-		case "извинись":
-			return handleApologize(c, chatMessageHandler)
-		default:
-			// Для победителя другие команды недоступны, обрабатываем как обычное сообщение
+	// Центральная проверка прав: каждая админская команда требует своё разрешение
+	if perm, isCommand := adminCommandPermission(text); isCommand && !chatMsg.HasPermission(perm) {
+		// Для владельца временной роли недоступные команды обрабатываются как обычное сообщение
+		if isTempRoleOnly {
 			if chatMessageHandler.QuizManager.IsRunning() {
 				ManageRunningQuiz(c, chatMessageHandler)
 			}
 			return nil
 		}
+		return handleNotEnoughRights(c, chatMessageHandler)
 	}
 
 	// Обработка команд админов
@@ -60,101 +64,50 @@ func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandl
 	case "извинись":
 		return handleApologize(c, chatMessageHandler)
 	case "пошел нахуй", "пошла нахуй", "пошёл нахуй", "иди нахуй", "в бан", "/ban":
-		// Только сеньоры могут банить
-		if chatMsg.AdminRole() == "senior" {
-			return handleBan(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
-	case "рестрикт", "кринж", "/restrict":
-		// Джуниоры и сеньоры могут рестриктить
-		if chatMsg.AdminRole() == "senior" || chatMsg.AdminRole() == "junior" {
-			return handleRestrict(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleBan(c, chatMessageHandler)
 	case "размут", "/unmute":
-		// Джуниоры и сеньоры могут размучивать
-		adminRole := chatMsg.AdminRole()
-		if adminRole == "junior" || adminRole == "senior" {
-			return handleUnmute(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleUnmute(c, chatMessageHandler)
 	case "нацик":
-		// Только сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" {
-			return handleNazik(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleNazik(c, chatMessageHandler)
 	case "обезглавить", "обоссать", "сжечь":
-		// Только сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" {
-			return handleDecapitate(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleDecapitate(c, chatMessageHandler)
 	case "разбан", "помиловать":
-		// Только сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" {
-			return handleUnban(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleUnban(c, chatMessageHandler)
 	case "кикнуть", "уйди отсюда":
-		// Только сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" {
-			return handleKick(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleKick(c, chatMessageHandler)
 	case "предупреждение всем", "всем предупреждение", "остановитесь!", "астанавитесь!":
-		// Джуниоры и сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" || chatMsg.AdminRole() == "junior" {
-			return handleWarnAll(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleWarnAll(c, chatMessageHandler)
 	case "минусануть":
-		// Джуниоры и сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" || chatMsg.AdminRole() == "junior" {
-			return handleUnwarn(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
+		return handleUnwarn(c, chatMessageHandler)
 	case "осуждаю":
-		// Джуниоры и сеньоры могут использовать эту команду
-		if chatMsg.AdminRole() == "senior" || chatMsg.AdminRole() == "junior" {
-			return handleCondemn(c, chatMessageHandler)
-		} else {
-			return handleNotEnoughRights(c, chatMessageHandler)
-		}
-		// Команды для тестирования:
-		// case "преф", "префикс":
-		// 	// Джуниоры и сеньоры могут использовать эту команду
-		// 	if chatMsg.AdminRole() == "senior" || chatMsg.AdminRole() == "junior" {
-		// 		member, err := chatMessageHandler.Bot.ChatMemberOf(c.Chat(), chatMsg.ReplyTo().Sender)
-		// 		if err != nil {
-		// 			return fmt.Errorf("failed to get chat member: %w", err)
-		// 		}
-		// 		admins.SetPref(chatMessageHandler.Bot, c.Chat(), member, "тестовый префикс")
-		// 		return messages.ReplyMessage(c, fmt.Sprintf("Префикс установлен для %s: %s", chatMsg.ReplyTo().Sender.Username, chatMsg.Text()), chatMsg.ThreadID())
-		// 	} else {
-		// 		return handleNotEnoughRights(c, chatMessageHandler)
-		// 	}
-		// case "убрать преф", "убрать префикс":
-		// 	// Джуниоры и сеньоры могут использовать эту команду
-		// 	if chatMsg.AdminRole() == "senior" || chatMsg.AdminRole() == "junior" {
-		// 		member, err := chatMessageHandler.Bot.ChatMemberOf(c.Chat(), chatMsg.ReplyTo().Sender)
-		// 		if err != nil {
-		// 			return fmt.Errorf("failed to get chat member: %w", err)
-		// 		}
-		// 		admins.RemovePrefTest(chatMessageHandler.Bot, c.Chat(), member, chatMessageHandler.Rep)
-		// 		return messages.ReplyMessage(c, "Префикс убран", chatMsg.ThreadID())
-		// 	} else {
-		// 		return handleNotEnoughRights(c, chatMessageHandler)
-		// 	}
+		return handleCondemn(c, chatMessageHandler)
+	}
+
+	// Карточка пользователя и заметки для админов
+	if text == "кто это" || text == "/whois" {
+		return handleWhoisReply(c, chatMessageHandler)
+	}
+	if text == "заметка" || strings.HasPrefix(text, "заметка ") || strings.HasPrefix(text, "заметка\n") {
+		return handleNoteReply(c, chatMessageHandler)
+	}
+
+	if text == "выпустить" || text == "/release" {
+		return handleReleaseReply(c, chatMessageHandler)
+	}
+
+	// Закрытие и открытие всего чата (может содержать срок)
+	if mode, rest, ok := parseLockdownCommand(text); ok {
+		return handleLockdown(c, chatMessageHandler, mode, rest)
+	}
+
+	// Зачистка сообщений (может содержать количество и "бан")
+	if isPurgeCommand(text) {
+		return handlePurge(c, chatMessageHandler)
+	}
+
+	// Обработка команды блоклиста (может содержать причину)
+	if strings.HasPrefix(text, "в блоклист") {
+		return handleBlocklistReply(c, chatMessageHandler)
 	}
 
 	// Обработка команды мута (может содержать число)
@@ -162,23 +115,21 @@ func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandl
 	if len(parts) > 0 {
 		prefix := parts[0]
 		if prefix == "мут" || prefix == "ебало" || prefix == "/mute" {
-			// Джуниоры и сеньоры могут мутить
-			adminRole := chatMsg.AdminRole()
-			if adminRole == "junior" || adminRole == "senior" {
-				var durationMinutes uint = 30 // стандартное значение
-				if len(parts) > 1 {
-					lastPart := parts[len(parts)-1]
-					lastPart = strings.Replace(lastPart, "-", "", 1)
-					if mins, err := strconv.Atoi(lastPart); err == nil && mins > 0 {
-						durationMinutes = uint(mins)
-					} else {
-						messages.ReplyMessage(c, "Нихрена не понял, на сколько мутить. Я фигану 30 минуток на всякий, в следующий раз выражайся понятнее", chatMsg.ThreadID())
-					}
+			var durationMinutes uint = 30 // стандартное значение
+			if len(parts) > 1 {
+				lastPart := parts[len(parts)-1]
+				lastPart = strings.Replace(lastPart, "-", "", 1)
+				if mins, err := strconv.Atoi(lastPart); err == nil && mins > 0 {
+					durationMinutes = uint(mins)
+				} else {
+					messages.ReplyMessage(c, "Нихрена не понял, на сколько мутить. Я фигану 30 минуток на всякий, в следующий раз выражайся понятнее", chatMsg.ThreadID())
 				}
-				return handleMute(c, chatMessageHandler, durationMinutes)
-			} else {
-				return handleNotEnoughRights(c, chatMessageHandler)
 			}
+			return handleMute(c, chatMessageHandler, durationMinutes)
+		}
+		// Рестрикт: "рестрикт [медиа|стикеры|гифки|ссылки] [минуты]", без срока - бессрочно
+		if preset, durationMinutes, ok := parseRestrictCommand(text); ok {
+			return handleRestrict(c, chatMessageHandler, preset, durationMinutes)
 		}
 	}
 
@@ -190,7 +141,98 @@ func handleAdminChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandl
 	return nil
 }
 
-func handleAdminPrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHandler, isWinnerOnly bool) error {
+// adminCommandPermission возвращает разрешение, необходимое для админской команды в чате.
+// Второе значение false, если текст не является админской командой
+func adminCommandPermission(text string) (string, bool) {
+	switch text {
+	case "предупреждение", "извинись", "минусануть", "осуждаю":
+		return database.PermWarn, true
+	case "пошел нахуй", "пошла нахуй", "пошёл нахуй", "иди нахуй", "в бан", "/ban",
+		"нацик", "обезглавить", "обоссать", "сжечь", "разбан", "помиловать":
+		return database.PermBan, true
+	case "размут", "/unmute":
+		return database.PermMute, true
+	case "кикнуть", "уйди отсюда":
+		return database.PermKick, true
+	case "предупреждение всем", "всем предупреждение", "остановитесь!", "астанавитесь!":
+		return database.PermBroadcast, true
+	}
+
+	if strings.HasPrefix(text, "в блоклист") {
+		return database.PermBan, true
+	}
+	if text == "выпустить" || text == "/release" {
+		return database.PermRestrict, true
+	}
+	if _, _, ok := parseLockdownCommand(text); ok {
+		return database.PermRestrict, true
+	}
+	// Для зачистки с баном дополнительно проверяется разрешение на бан
+	if isPurgeCommand(text) {
+		return database.PermRestrict, true
+	}
+	parts := strings.Fields(text)
+	if len(parts) > 0 {
+		switch parts[0] {
+		case "мут", "ебало", "/mute":
+			return database.PermMute, true
+		}
+	}
+	if _, _, ok := parseRestrictCommand(text); ok {
+		return database.PermRestrict, true
+	}
+	return "", false
+}
+
+// parseRestrictCommand разбирает команду "рестрикт [пресет] [минуты]". Команда распознается, только если
+// после слова команды идут лишь пресет и/или срок, иначе это обычное сообщение ("кринж какой-то")
+func parseRestrictCommand(text string) (string, uint, bool) {
+	parts := strings.Fields(text)
+	if len(parts) == 0 || len(parts) > 3 {
+		return "", 0, false
+	}
+	switch parts[0] {
+	case "рестрикт", "кринж", "/restrict":
+	default:
+		return "", 0, false
+	}
+	preset := admins.RestrictMedia
+	var durationMinutes uint
+	presetSet, durationSet := false, false
+	for _, part := range parts[1:] {
+		if p, ok := admins.ParseRestrictPreset(part); ok && !presetSet {
+			preset = p
+			presetSet = true
+			continue
+		}
+		if mins, err := strconv.Atoi(strings.Replace(part, "-", "", 1)); err == nil && mins > 0 && !durationSet {
+			durationMinutes = uint(mins)
+			durationSet = true
+			continue
+		}
+		return "", 0, false
+	}
+	return preset, durationMinutes, true
+}
+
+// adminPrivateCommandPermission возвращает разрешение, необходимое для админской команды в личных сообщениях
+func adminPrivateCommandPermission(text string) (string, bool) {
+	switch {
+	case text == "/quiz" || text == "quiz" || text == "квиз":
+		return database.PermManageQuiz, true
+	case strings.HasPrefix(text, "размут"):
+		return database.PermMute, true
+	case strings.HasPrefix(text, "/block"):
+		return database.PermBan, true
+	case isAliasCommand(text):
+		return database.PermManageQuiz, true
+	case strings.HasPrefix(text, "выпустить") || strings.HasPrefix(text, "/release"):
+		return database.PermRestrict, true
+	}
+	return "", false
+}
+
+func handleAdminPrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHandler, isTempRoleOnly bool) error {
 	chatMsg := chatMessageHandler.ChatMessage
 	if chatMsg == nil {
 		return fmt.Errorf("chat message is nil")
@@ -198,6 +240,54 @@ func handleAdminPrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHa
 	text := strings.ToLower(chatMsg.Text())
 	userID := chatMsg.UserData().UserID
 
+	// Центральная проверка прав для команд в личных сообщениях
+	if perm, isCommand := adminPrivateCommandPermission(text); isCommand && !chatMsg.HasPermission(perm) {
+		return c.Send("У тебя недостаточно прав для выполнения этой команды.")
+	}
+
+	// Управление ролями доступно только главному админу
+	if isRoleCommand(text) {
+		if userID != chatMessageHandler.MainAdminID {
+			return c.Send("Управлять ролями может только главный админ.")
+		}
+		return handleRoleCommand(c, chatMessageHandler)
+	}
+
+	if strings.HasPrefix(text, "/whois") || strings.HasPrefix(text, "кто это") {
+		return handleWhoisCommand(c, chatMessageHandler)
+	}
+	if strings.HasPrefix(text, "заметка") || strings.HasPrefix(text, "/note") {
+		return handleNoteCommand(c, chatMessageHandler)
+	}
+	if strings.HasPrefix(text, "/archive") || strings.HasPrefix(text, "архив") {
+		return handleArchiveSearch(c, chatMessageHandler)
+	}
+
+	if isQuizItemCommand(text) {
+		if userID != chatMessageHandler.MainAdminID {
+			return c.Send("Вопросами квиза управляет только главный админ.")
+		}
+		return handleQuizItemCommand(c, chatMessageHandler)
+	}
+
+	if isAliasCommand(text) {
+		return handleAliasCommand(c, chatMessageHandler)
+	}
+
+	if strings.HasPrefix(text, "/adminstats") {
+		if userID != chatMessageHandler.MainAdminID {
+			return c.Send("Статистику админов может смотреть только главный админ.")
+		}
+		return handleAdminStats(c, chatMessageHandler)
+	}
+
+	if strings.HasPrefix(text, "/spamlog") {
+		if userID != chatMessageHandler.MainAdminID {
+			return c.Send("Журнал антиспама может смотреть только главный админ.")
+		}
+		return handleSpamLog(c, chatMessageHandler)
+	}
+
 	// Обработка команд в личных сообщениях
 	switch text {
 	case "/start", "меню", "/menu":
@@ -209,6 +299,8 @@ func handleAdminPrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHa
 		return handleShowQuizInfo(c, chatMessageHandler)
 	case "/horoscope":
 		return handleHoroscope(c, chatMessageHandler)
+	case "топ квиза", "/quiztop":
+		return handleQuizTop(c, chatMessageHandler)
 	}
 
 	if strings.HasPrefix(text, "размут") {
@@ -227,9 +319,14 @@ func handleAdminPrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHa
 			return c.Send(fmt.Sprintf("Ошибка Телеграмма %v\nПопробуй ещё раз, если повторится, дерни Бабича", err))
 		}
 		admins.UnmuteUser(chatMessageHandler.Bot, chat, chatMember, chatMessageHandler.Rep)
+		if err := chatMessageHandler.Rep.RevertLatestAction(userID, []string{admins.ActionMute, admins.ActionRestrict}); err != nil {
+			log.Printf("Failed to mark mute of %d as reverted: %v", userID, err)
+		}
 		return c.Send(fmt.Sprintf("Размутил пользователя %d", userID))
-	} else if strings.HasPrefix(text, "/promote") {
-		return handlePromoteAdmin(c, chatMessageHandler)
+	} else if strings.HasPrefix(text, "/block") {
+		return handleBlocklistCommand(c, chatMessageHandler)
+	} else if strings.HasPrefix(text, "выпустить") || strings.HasPrefix(text, "/release") {
+		return handleReleaseCommand(c, chatMessageHandler)
 	}
 
 	// Проверка на формат даты рождения (DD.MM.YYYY)
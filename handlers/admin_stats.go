@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	textcases "saxbot/text_cases"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Сколько после вызова админов ответ админа в чате засчитывается как реакция на вызов
+const reportResponseWindow = 2 * time.Hour
+
+// respondToReports отмечает открытые вызовы админов в чате как отвеченные отправителем
+func respondToReports(chatMessageHandler *ChatMessageHandler) {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil || chatMsg.Chat() == nil {
+		return
+	}
+	err := chatMessageHandler.Rep.RespondAdminReports(chatMsg.Chat().ID, actorID(chatMsg), time.Now().Add(-reportResponseWindow))
+	if err != nil {
+		log.Printf("failed to respond admin reports: %v", err)
+	}
+}
+
+// buildAdminStatsReport собирает отчет по модерации за days дней
+func buildAdminStatsReport(chatMessageHandler *ChatMessageHandler, days int) (string, error) {
+	since := time.Now().AddDate(0, 0, -days)
+	stats, unanswered, err := chatMessageHandler.Rep.GetAdminStats(since)
+	if err != nil {
+		return "", err
+	}
+	names := make(map[int64]string, len(stats))
+	for _, s := range stats {
+		user, err := chatMessageHandler.Rep.GetUser(s.AdminID)
+		if err != nil || (user.Username == "" && user.FirstName == "") {
+			continue
+		}
+		if user.Username != "" {
+			names[s.AdminID] = "@" + user.Username
+		} else {
+			names[s.AdminID] = user.FirstName
+		}
+	}
+	return textcases.GetAdminStatsReport(stats, names, days, unanswered), nil
+}
+
+// handleAdminStats обрабатывает команду /adminstats [дней] (только главный админ)
+func handleAdminStats(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	parts := strings.Fields(chatMessageHandler.ChatMessage.Text())
+	days := 7
+	if len(parts) > 1 {
+		value, err := strconv.Atoi(parts[1])
+		if err != nil || value <= 0 {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/adminstats [количество дней]\"")
+		}
+		days = value
+	}
+	report, err := buildAdminStatsReport(chatMessageHandler, days)
+	if err != nil {
+		log.Printf("Failed to build admin stats: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	return c.Send(report)
+}
+
+// SendWeeklyAdminStats отправляет главному админу статистику модерации за неделю
+func SendWeeklyAdminStats(chatMessageHandler *ChatMessageHandler) {
+	if chatMessageHandler.MainAdminID == 0 {
+		return
+	}
+	report, err := buildAdminStatsReport(chatMessageHandler, 7)
+	if err != nil {
+		log.Printf("failed to build weekly admin stats: %v", err)
+		return
+	}
+	_, err = chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, fmt.Sprintf("Еженедельный отчет\n\n%s", report))
+	if err != nil {
+		log.Printf("failed to send weekly admin stats: %v", err)
+	}
+}
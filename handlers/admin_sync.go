@@ -0,0 +1,44 @@
+package handlers
+
+import (
+	"log"
+	"saxbot/admins"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// SyncAdmins сверяет админов бота с администраторами чатов, обновляет список юзернеймов для вызова админов
+// и сообщает главному админу о расхождениях (один и тот же отчет повторно не отправляется)
+func SyncAdmins(chatMessageHandler *ChatMessageHandler) {
+	protected := append([]int64{chatMessageHandler.MainAdminID}, chatMessageHandler.AdminsList...)
+	result, err := admins.SyncChatAdmins(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, protected, chatMessageHandler.Rep)
+	if err != nil {
+		log.Printf("failed to sync admins with chats: %v", err)
+		return
+	}
+
+	if len(result.Usernames) > 0 {
+		chatMessageHandler.SetAdminsUsernames(result.Usernames)
+	}
+
+	if !result.HasMismatches() || chatMessageHandler.MainAdminID == 0 {
+		chatMessageHandler.adminsMu.Lock()
+		chatMessageHandler.lastAdminSyncReport = ""
+		chatMessageHandler.adminsMu.Unlock()
+		return
+	}
+
+	report := result.Report()
+	chatMessageHandler.adminsMu.Lock()
+	alreadySent := report == chatMessageHandler.lastAdminSyncReport
+	chatMessageHandler.lastAdminSyncReport = report
+	chatMessageHandler.adminsMu.Unlock()
+	if alreadySent {
+		return
+	}
+
+	_, err = chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, report)
+	if err != nil {
+		log.Printf("failed to send admin sync report to main admin: %v", err)
+	}
+}
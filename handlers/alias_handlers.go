@@ -0,0 +1,113 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/activities"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"strconv"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// reloadQuizAliases обновляет прозвища песни идущего квиза после изменения прозвищ
+func reloadQuizAliases(chatMessageHandler *ChatMessageHandler) {
+	todayQuiz, quizRunning, _, _, _, _ := chatMessageHandler.QuizManager.GetState()
+	if quizRunning {
+		chatMessageHandler.QuizManager.SetAliases(activities.LoadQuizAliases(chatMessageHandler.Rep, todayQuiz.SongName))
+	}
+}
+
+// isAliasCommand проверяет, является ли текст командой управления прозвищами песен
+func isAliasCommand(text string) bool {
+	return strings.HasPrefix(text, "/alias") || strings.HasPrefix(text, "/unalias")
+}
+
+// Обработка команд "/alias <песня> = <прозвище>", "/aliases [песня]" и "/unalias <id>" в личных сообщениях
+func handleAliasCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	text := strings.TrimSpace(chatMessageHandler.ChatMessage.Text())
+	command, args, _ := strings.Cut(text, " ")
+	args = strings.TrimSpace(args)
+
+	switch strings.ToLower(command) {
+	case "/aliases":
+		return handleListAliases(c, chatMessageHandler, args)
+	case "/unalias":
+		id, err := strconv.ParseUint(args, 10, 64)
+		if err != nil {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/unalias [id]\"")
+		}
+		deleted, err := chatMessageHandler.Rep.DeleteSongAlias(uint(id))
+		if err != nil {
+			log.Printf("Failed to delete song alias %d: %v", id, err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		if !deleted {
+			return c.Send(fmt.Sprintf("Прозвища с ID %d нет", id))
+		}
+		reloadQuizAliases(chatMessageHandler)
+		return c.Send(fmt.Sprintf("Прозвище %d удалено", id))
+	case "/alias":
+		song, alias, ok := strings.Cut(args, "=")
+		song, alias = strings.TrimSpace(song), strings.TrimSpace(alias)
+		if !ok || song == "" || alias == "" {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/alias [песня] = [прозвище]\"")
+		}
+		title, found := textcases.FindTrackTitle(song)
+		if !found {
+			return c.Send(fmt.Sprintf("Песни \"%s\" нет в треклистах. Название пиши как в каталоге", song))
+		}
+		song = title
+		entry := &database.SongAlias{SongName: textcases.NormalizeAnswer(song), Alias: alias, AddedBy: chatMessageHandler.ChatMessage.Sender().ID}
+		if err := chatMessageHandler.Rep.AddSongAlias(entry); err != nil {
+			log.Printf("Failed to add song alias: %v", err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		reloadQuizAliases(chatMessageHandler)
+		return c.Send(fmt.Sprintf("Теперь ответ \"%s\" засчитывается за песню \"%s\" (ID прозвища %d)", alias, song, entry.ID))
+	}
+	return c.Send("Команды прозвищ: \"/alias [песня] = [прозвище]\", \"/aliases [песня]\", \"/unalias [id]\"")
+}
+
+// handleListAliases показывает прозвища одной песни или все добавленные прозвища
+func handleListAliases(c tele.Context, chatMessageHandler *ChatMessageHandler, song string) error {
+	var (
+		aliases []database.SongAlias
+		err     error
+	)
+	if song != "" {
+		if title, found := textcases.FindTrackTitle(song); found {
+			song = title
+		}
+		aliases, err = chatMessageHandler.Rep.GetSongAliases(textcases.NormalizeAnswer(song))
+	} else {
+		aliases, err = chatMessageHandler.Rep.GetAllSongAliases()
+	}
+	if err != nil {
+		log.Printf("Failed to get song aliases: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+
+	var sb strings.Builder
+	if song != "" {
+		sb.WriteString(fmt.Sprintf("Прозвища песни \"%s\":\n", song))
+		for _, alias := range textcases.DefaultSongAliases(song) {
+			sb.WriteString(fmt.Sprintf("- %s (встроенное)\n", alias))
+		}
+	} else {
+		sb.WriteString("Добавленные прозвища песен:\n")
+	}
+	for _, alias := range aliases {
+		if song != "" {
+			sb.WriteString(fmt.Sprintf("- %s (ID %d)\n", alias.Alias, alias.ID))
+		} else {
+			sb.WriteString(fmt.Sprintf("- %s: %s (ID %d)\n", alias.SongName, alias.Alias, alias.ID))
+		}
+	}
+	if len(aliases) == 0 && (song == "" || len(textcases.DefaultSongAliases(song)) == 0) {
+		sb.WriteString("пока нет\n")
+	}
+	return c.Send(sb.String())
+}
@@ -0,0 +1,119 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+const archiveSearchLimit = 20
+
+// archiveMessage сохраняет сообщение в архив, если архив включен для этого чата
+func archiveMessage(chatMessageHandler *ChatMessageHandler, msg *tele.Message) {
+	if msg == nil || msg.Chat == nil || !slices.Contains(chatMessageHandler.Archive.Chats, msg.Chat.ID) {
+		return
+	}
+	entry := &database.ArchivedMessage{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		Text:      msg.Text,
+		SentAt:    msg.Time(),
+	}
+	if entry.Text == "" {
+		entry.Text = msg.Caption
+	}
+	switch {
+	case msg.SenderChat != nil:
+		entry.SenderID = msg.SenderChat.ID
+		entry.SenderName = msg.SenderChat.Title
+	case msg.Sender != nil:
+		entry.SenderID = msg.Sender.ID
+		entry.SenderName = msg.Sender.FirstName
+		if msg.Sender.Username != "" {
+			entry.SenderName = "@" + msg.Sender.Username
+		}
+	}
+	if msg.ReplyTo != nil {
+		entry.ReplyToID = msg.ReplyTo.ID
+	}
+	if err := chatMessageHandler.Rep.ArchiveMessage(entry); err != nil {
+		log.Printf("Failed to archive message: %v", err)
+	}
+}
+
+// messageLink возвращает ссылку на сообщение в супергруппе или пустую строку для обычных групп
+func messageLink(chatID int64, messageID int) string {
+	id := strconv.FormatInt(chatID, 10)
+	if !strings.HasPrefix(id, "-100") {
+		return ""
+	}
+	return fmt.Sprintf("https://t.me/c/%s/%d", strings.TrimPrefix(id, "-100"), messageID)
+}
+
+// Обработка команды поиска по архиву в личных сообщениях:
+// "/archive <id или @username> [слово]" или "/archive <слово>" (также "архив ...")
+func handleArchiveSearch(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if !canSeeDossier(chatMessageHandler) {
+		return c.Send("Архив доступен только админам.")
+	}
+	if len(chatMessageHandler.Archive.Chats) == 0 {
+		return c.Send("Архив сообщений выключен. Чтобы включить, укажи чаты в ARCHIVE_CHATS")
+	}
+
+	parts := strings.Fields(chatMsg.Text())
+	if len(parts) < 2 {
+		return c.Send("Вводи в формате \"/archive [id или @username] [слово]\" или \"/archive [слово]\"")
+	}
+
+	var senderID int64
+	args := parts[1:]
+	if _, err := strconv.ParseInt(args[0], 10, 64); err == nil || strings.HasPrefix(args[0], "@") {
+		userID, err := resolveUserQuery(chatMessageHandler, args[0])
+		if err != nil {
+			log.Printf("Failed to resolve user %s: %v", args[0], err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		if userID == 0 {
+			return c.Send(fmt.Sprintf("Пользователь %s не найден в базе", args[0]))
+		}
+		senderID = userID
+		args = args[1:]
+	}
+	keyword := strings.Join(args, " ")
+
+	found, err := chatMessageHandler.Rep.SearchArchive(senderID, keyword, archiveSearchLimit)
+	if err != nil {
+		log.Printf("Failed to search archive: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if len(found) == 0 {
+		return c.Send("В архиве ничего не найдено")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Найдено в архиве (последние %d):\n", len(found)))
+	for _, msg := range found {
+		text := msg.Text
+		if utf8.RuneCountInString(text) > 200 {
+			text = string([]rune(text)[:200]) + "…"
+		}
+		if text == "" {
+			text = "[медиа без подписи]"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s %s (%d): %s\n", msg.SentAt.In(database.MoscowTZ).Format("02.01.2006 15:04"), msg.SenderName, msg.SenderID, text))
+		if link := messageLink(msg.ChatID, msg.MessageID); link != "" {
+			sb.WriteString(link + "\n")
+		}
+	}
+	return c.Send(sb.String(), &tele.SendOptions{DisableWebPagePreview: true})
+}
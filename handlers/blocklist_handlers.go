@@ -0,0 +1,243 @@
+package handlers
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/messages"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// blocklistSource возвращает источник для записей, добавленных вручную в этом экземпляре бота
+func blocklistSource(chatMessageHandler *ChatMessageHandler) string {
+	if chatMessageHandler.Bot != nil && chatMessageHandler.Bot.Me != nil && chatMessageHandler.Bot.Me.Username != "" {
+		return "@" + chatMessageHandler.Bot.Me.Username
+	}
+	return "manual"
+}
+
+// Обработка команды "в блоклист" ответом на сообщение в чате
+func handleBlocklistReply(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if !chatMsg.IsReply() || chatMsg.ReplyToIsChannel() {
+		return messages.ReplyMessage(c, "Кого в блоклист? Ответь на сообщение пользователя", chatMsg.ThreadID())
+	}
+	if chatMsg.ReplyToAdmin() {
+		return messages.ReplyMessage(c, "Ты не можешь отправить в блоклист других админов, соси писос", chatMsg.ThreadID())
+	}
+
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionBlocklist, 0) {
+		return nil
+	}
+	reason := strings.TrimSpace(chatMsg.Text()[len("в блоклист"):])
+	entry := database.BlocklistEntry{
+		UserID:  chatMsg.ReplyToID(),
+		Reason:  reason,
+		Source:  blocklistSource(chatMessageHandler),
+		AddedBy: chatMsg.Sender().ID,
+	}
+	if err := chatMessageHandler.Rep.AddToBlocklist(entry); err != nil {
+		log.Printf("Failed to add user %d to blocklist: %v", entry.UserID, err)
+		return messages.ReplyMessage(c, "Не удалось добавить пользователя в блоклист", chatMsg.ThreadID())
+	}
+	recordAction(chatMessageHandler, admins.ActionBlocklist, entry.UserID, 0, 0)
+	chatMessageHandler.Bot.Delete(chatMsg.ReplyTo())
+	admins.SweepBlocklisted(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, entry.UserID, chatMessageHandler.Rep)
+	return messages.ReplyMessage(c, fmt.Sprintf("%s отправляется в блоклист и идет нахуй из всех наших чатиков", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
+}
+
+// Обработка команд блоклиста в личных сообщениях:
+// /blocklist, /blockadd <id> [причина], /blockdel <id>, /blockexport [json|csv]
+func handleBlocklistCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	parts := strings.Fields(chatMsg.Text())
+	command := strings.ToLower(parts[0])
+
+	switch command {
+	case "/blocklist":
+		entries, err := chatMessageHandler.Rep.GetBlocklist()
+		if err != nil {
+			log.Printf("Failed to get blocklist: %v", err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		return c.Send(fmt.Sprintf(`В блоклисте %d пользователей.
+/blockadd [id] [причина] - добавить в блоклист и выгнать из всех чатов
+/blockdel [id] - удалить из блоклиста и снять бан
+/blockexport [json|csv] - выгрузить блоклист файлом
+Чтобы импортировать блоклист, пришли мне файл .json или .csv`, len(entries)))
+
+	case "/blockadd":
+		if len(parts) < 2 {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/blockadd [id] [причина]\"")
+		}
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/blockadd [id] [причина]\"")
+		}
+		if chatMessageHandler.Rep.IsAdmin(userID) {
+			return c.Send("Админа нельзя отправить в блоклист")
+		}
+		if !checkActionLimit(c, chatMessageHandler, admins.ActionBlocklist, 0) {
+			return nil
+		}
+		entry := database.BlocklistEntry{
+			UserID:  userID,
+			Reason:  strings.Join(parts[2:], " "),
+			Source:  blocklistSource(chatMessageHandler),
+			AddedBy: chatMsg.Sender().ID,
+		}
+		if err := chatMessageHandler.Rep.AddToBlocklist(entry); err != nil {
+			log.Printf("Failed to add user %d to blocklist: %v", userID, err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		recordAction(chatMessageHandler, admins.ActionBlocklist, userID, 0, 0)
+		swept := admins.SweepBlocklisted(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, userID, chatMessageHandler.Rep)
+		return c.Send(fmt.Sprintf("Пользователь %d добавлен в блоклист. Выгнан из чатов: %d", userID, swept))
+
+	case "/blockdel":
+		if len(parts) != 2 {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/blockdel [id]\"")
+		}
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/blockdel [id]\"")
+		}
+		removed, err := chatMessageHandler.Rep.RemoveFromBlocklist(userID)
+		if err != nil {
+			log.Printf("Failed to remove user %d from blocklist: %v", userID, err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		if !removed {
+			return c.Send(fmt.Sprintf("Пользователя %d нет в блоклисте", userID))
+		}
+		admins.UnsweepBlocklisted(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, userID, chatMessageHandler.Rep)
+		if err := chatMessageHandler.Rep.RevertLatestAction(userID, []string{admins.ActionBlocklist, admins.ActionBan}); err != nil {
+			log.Printf("Failed to mark blocklist of %d as reverted: %v", userID, err)
+		}
+		return c.Send(fmt.Sprintf("Пользователь %d удален из блоклиста и разбанен", userID))
+
+	case "/blockexport":
+		format := "json"
+		if len(parts) > 1 {
+			format = strings.ToLower(parts[1])
+		}
+		entries, err := chatMessageHandler.Rep.GetBlocklist()
+		if err != nil {
+			log.Printf("Failed to get blocklist: %v", err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		var data []byte
+		switch format {
+		case "json":
+			data, err = admins.ExportBlocklistJSON(entries)
+		case "csv":
+			data, err = admins.ExportBlocklistCSV(entries)
+		default:
+			return c.Send("Поддерживаются только форматы json и csv")
+		}
+		if err != nil {
+			log.Printf("Failed to export blocklist: %v", err)
+			return c.Send("Не удалось выгрузить блоклист")
+		}
+		doc := &tele.Document{
+			File:     tele.FromReader(bytes.NewReader(data)),
+			FileName: fmt.Sprintf("blocklist_%s.%s", time.Now().In(database.MoscowTZ).Format("2006-01-02"), format),
+			Caption:  fmt.Sprintf("Блоклист: %d записей", len(entries)),
+		}
+		return c.Send(doc)
+	}
+
+	return nil
+}
+
+// HandlePrivateDocument обрабатывает файлы в личных сообщениях (импорт блоклиста)
+func HandlePrivateDocument(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMessage, err := initPrivateMessage(c, chatMessageHandler)
+	if err != nil {
+		log.Printf("Failed to initialize chat message: %v", err)
+		return nil
+	}
+	chatMessageHandler.ChatMessage = chatMessage
+
+	if !chatMessage.HasPermission(database.PermBan) {
+		return nil
+	}
+
+	doc := c.Message().Document
+	if doc == nil {
+		return nil
+	}
+	name := strings.ToLower(doc.FileName)
+	if !strings.HasSuffix(name, ".json") && !strings.HasSuffix(name, ".csv") {
+		return c.Send("Для импорта блоклиста пришли файл .json или .csv")
+	}
+
+	reader, err := chatMessageHandler.Bot.File(&doc.File)
+	if err != nil {
+		log.Printf("Failed to download blocklist file %s: %v", doc.FileName, err)
+		return c.Send(fmt.Sprintf("Не удалось скачать файл: %v", err))
+	}
+	defer reader.Close()
+
+	entries, err := admins.ParseBlocklist(doc.FileName, reader)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Не удалось разобрать файл: %v", err))
+	}
+	// Админов бота в блоклист не берем, иначе они будут забанены при входе в чат
+	adminsMap, err := chatMessageHandler.Rep.GetAllAdminsMap()
+	if err != nil {
+		log.Printf("Failed to get admins for blocklist import: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	total := len(entries)
+	skipped := 0
+	filtered := entries[:0]
+	for _, entry := range entries {
+		if _, isAdmin := adminsMap[entry.UserID]; isAdmin || entry.UserID == chatMessageHandler.MainAdminID {
+			skipped++
+			continue
+		}
+		entry.AddedBy = chatMessage.Sender().ID
+		filtered = append(filtered, entry)
+	}
+	entries = filtered
+
+	added, err := chatMessageHandler.Rep.ImportBlocklist(entries)
+	if err != nil {
+		log.Printf("Failed to import blocklist: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	report := fmt.Sprintf("Импортировано записей: %d из %d.", added, total)
+	if skipped > 0 {
+		report += fmt.Sprintf(" Пропущено админов: %d.", skipped)
+	}
+	c.Send(report + " Выметаю их из чатов...")
+
+	// Выметаем пользователей в фоне — на большом списке это долго из-за лимитов Telegram
+	go func(chat *tele.Chat) {
+		swept := 0
+		for _, entry := range entries {
+			if entry.UserID == 0 {
+				continue
+			}
+			if admins.SweepBlocklisted(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, entry.UserID, chatMessageHandler.Rep) > 0 {
+				swept++
+			}
+		}
+		chatMessageHandler.Bot.Send(chat, fmt.Sprintf("Импорт блоклиста завершен. Выгнано пользователей: %d", swept))
+	}(c.Chat())
+
+	return nil
+}
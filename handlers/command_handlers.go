@@ -1,16 +1,13 @@
 package handlers
 
 import (
-	"errors"
 	"fmt"
 	"log"
+	"saxbot/activities"
 	"saxbot/admins"
 	"saxbot/database"
 	"saxbot/messages"
 	textcases "saxbot/text_cases"
-	"slices"
-	"strconv"
-	"strings"
 	"time"
 
 	tele "gopkg.in/telebot.v4"
@@ -59,6 +56,7 @@ func handleWarn(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 			}
 		}
 	}
+	recordAction(chatMessageHandler, admins.ActionWarn, replyToID, c.Chat().ID, 0)
 
 	var text string
 	replyTo := chatMsg.ReplyTo()
@@ -114,9 +112,13 @@ func handleBan(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 		return fmt.Errorf("reply message or sender is nil")
 	}
 
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionBan, 0) {
+		return nil
+	}
 	user := replyTo.Sender
 	chatMember := &tele.ChatMember{User: user, Role: tele.Member}
 	admins.BanUser(chatMessageHandler.Bot, c.Message().Chat, chatMember, chatMessageHandler.Rep)
+	recordAction(chatMessageHandler, admins.ActionBan, user.ID, c.Chat().ID, 0)
 	chatMessageHandler.Bot.Delete(replyTo)
 	return messages.ReplyMessage(c, fmt.Sprintf("%s идет нахуй из чатика", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 }
@@ -153,10 +155,14 @@ func handleUnban(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 
 	user := replyTo.Sender
 	admins.UnbanUser(chatMessageHandler.Bot, c.Message().Chat, user, chatMessageHandler.Rep)
+	if err := chatMessageHandler.Rep.RevertLatestAction(user.ID, []string{admins.ActionBan}); err != nil {
+		log.Printf("Failed to mark ban of %d as reverted: %v", user.ID, err)
+	}
 	return messages.ReplyMessage(c, fmt.Sprintf("%s помилован. Больше не шали!", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 }
 
-func handleRestrict(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+// handleRestrict рестриктит пользователя по пресету (см. admins.RestrictionRights) на durationMinutes минут, 0 - бессрочно
+func handleRestrict(c tele.Context, chatMessageHandler *ChatMessageHandler, preset string, durationMinutes uint) error {
 	chatMsg := chatMessageHandler.ChatMessage
 	if chatMsg == nil {
 		return fmt.Errorf("chat message is nil")
@@ -190,13 +196,21 @@ func handleRestrict(c tele.Context, chatMessageHandler *ChatMessageHandler) erro
 		return fmt.Errorf("reply message or sender is nil")
 	}
 
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionRestrict, durationMinutes) {
+		return nil
+	}
 	user := replyTo.Sender
 	chatMember := &tele.ChatMember{User: user, Role: tele.Member}
-	if err := admins.RestrictUser(chatMessageHandler.Bot, c.Message().Chat, chatMember, chatMessageHandler.Rep); err != nil {
+	if err := admins.RestrictUser(chatMessageHandler.Bot, c.Message().Chat, chatMember, chatMessageHandler.Rep, preset, durationMinutes); err != nil {
 		log.Printf("Failed to restrict user: %v", err)
 		return messages.ReplyMessage(c, "Не удалось рестриктить пользователя", chatMsg.ThreadID())
 	}
-	return messages.ReplyMessage(c, fmt.Sprintf("%s рестрикнут. Даже я словил кринж. А я бот ваще-то", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
+	recordAction(chatMessageHandler, admins.ActionRestrict, user.ID, c.Chat().ID, durationMinutes)
+	term := ""
+	if durationMinutes > 0 {
+		term = fmt.Sprintf(" на %d минут", durationMinutes)
+	}
+	return messages.ReplyMessage(c, fmt.Sprintf("%s рестрикнут (%s)%s. Даже я словил кринж. А я бот ваще-то", chatMsg.ReplyToAppeal(), admins.RestrictPresetTitle(preset), term), chatMsg.ThreadID())
 }
 
 func handleUnmute(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
@@ -237,6 +251,9 @@ func handleUnmute(c tele.Context, chatMessageHandler *ChatMessageHandler) error
 		},
 	}
 	admins.UnmuteUser(chatMessageHandler.Bot, c.Chat(), chatMember, chatMessageHandler.Rep)
+	if err := chatMessageHandler.Rep.RevertLatestAction(replyTo.Sender.ID, []string{admins.ActionMute, admins.ActionRestrict}); err != nil {
+		log.Printf("Failed to mark mute of %d as reverted: %v", replyTo.Sender.ID, err)
+	}
 	return messages.ReplyMessage(c, fmt.Sprintf("%s размучен. А то че как воды в рот набрал", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 }
 
@@ -277,6 +294,9 @@ func handleMute(c tele.Context, chatMessageHandler *ChatMessageHandler, duration
 		return fmt.Errorf("reply message or sender is nil")
 	}
 
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionMute, durationMinutes) {
+		return nil
+	}
 	user := replyTo.Sender
 	chatMember := &tele.ChatMember{
 		User: user,
@@ -287,6 +307,7 @@ func handleMute(c tele.Context, chatMessageHandler *ChatMessageHandler, duration
 	}
 
 	admins.MuteUser(chatMessageHandler.Bot, c.Chat(), chatMember, chatMessageHandler.Rep, durationMinutes)
+	recordAction(chatMessageHandler, admins.ActionMute, user.ID, c.Chat().ID, durationMinutes)
 	return messages.ReplyMessage(c, fmt.Sprintf("%s помолчит %d минут и подумает о своем поведении", chatMsg.ReplyToAppeal(), durationMinutes), chatMsg.ThreadID())
 }
 
@@ -327,11 +348,15 @@ func handleNazik(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 		return fmt.Errorf("reply message or sender is nil")
 	}
 
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionBan, 0) {
+		return nil
+	}
 	user := replyTo.Sender
 	messages.ReplyToOriginalMessage(c, fmt.Sprintf("%s, скажи ауфидерзейн своим нацистским яйцам!", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 	time.Sleep(1 * time.Second)
 	chatMember := &tele.ChatMember{User: user, Role: tele.Member}
 	admins.BanUser(chatMessageHandler.Bot, c.Message().Chat, chatMember, chatMessageHandler.Rep)
+	recordAction(chatMessageHandler, admins.ActionBan, user.ID, c.Chat().ID, 0)
 	chatMessageHandler.Bot.Delete(replyTo)
 	return messages.ReplyMessage(c, fmt.Sprintf("%s идет нахуй из чатика", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 }
@@ -373,11 +398,15 @@ func handleDecapitate(c tele.Context, chatMessageHandler *ChatMessageHandler) er
 		return fmt.Errorf("reply message or sender is nil")
 	}
 
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionBan, 0) {
+		return nil
+	}
 	user := replyTo.Sender
 	messages.ReplyToOriginalMessage(c, "ОБЕЗГЛАВИТЬ ОБОССАТЬ И СЖЕЧЬ!!!", chatMsg.ThreadID())
 	time.Sleep(1 * time.Second)
 	chatMember := &tele.ChatMember{User: user, Role: tele.Member}
 	admins.BanUser(chatMessageHandler.Bot, c.Message().Chat, chatMember, chatMessageHandler.Rep)
+	recordAction(chatMessageHandler, admins.ActionBan, user.ID, c.Chat().ID, 0)
 	chatMessageHandler.Bot.Delete(replyTo)
 	return messages.ReplyMessage(c, fmt.Sprintf("%s идет нахуй из чатика. АВЕ АВЕ ПИРОМАН!", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 }
@@ -410,7 +439,12 @@ func handleReport(c tele.Context, chatMessageHandler *ChatMessageHandler) error
 	}
 	log.Printf("Got an admin command from %d", senderID)
 
-	text := textcases.GetAdminsCommand(chatMsg.Appeal(), chatMessageHandler.AdminsUsernames)
+	report := &database.AdminReport{ChatID: c.Chat().ID, ReporterID: senderID}
+	if err := chatMessageHandler.Rep.SaveAdminReport(report); err != nil {
+		log.Printf("Failed to save admin report: %v", err)
+	}
+
+	text := textcases.GetAdminsCommand(chatMsg.Appeal(), chatMessageHandler.GetAdminsUsernames())
 	if chatMsg.IsReply() {
 		return messages.ReplyToOriginalMessage(c, text, chatMsg.ThreadID())
 	} else {
@@ -498,12 +532,16 @@ func handleKick(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 		return fmt.Errorf("reply message or sender is nil")
 	}
 
+	if !checkActionLimit(c, chatMessageHandler, admins.ActionKick, 0) {
+		return nil
+	}
 	user := replyTo.Sender
 	chatMember := &tele.ChatMember{User: user, Role: tele.Member}
 	err := admins.KickUser(chatMessageHandler.Bot, chatMsg.Chat(), chatMember)
 	if err != nil {
 		return fmt.Errorf("can't kick user %d: %w", user.ID, err)
 	}
+	recordAction(chatMessageHandler, admins.ActionKick, user.ID, chatMsg.Chat().ID, 0)
 	return messages.ReplyMessage(c, fmt.Sprintf("%s покидает нас", chatMsg.ReplyToAppeal()), chatMsg.ThreadID())
 }
 
@@ -518,9 +556,6 @@ func handleWarnAll(c tele.Context, chatMessageHandler *ChatMessageHandler) error
 
 // Обработка команды показать информацию по сегодняшнему квизу (для админов)
 func handleShowQuizInfo(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
-	if !slices.Contains(chatMessageHandler.AdminsList, c.Message().Sender.ID) {
-		return nil
-	}
 	time := chatMessageHandler.QuizManager.TodayQuiz.QuizTime.In(database.MoscowTZ).Format("15:04")
 	text := fmt.Sprintf("Информация о сегодняшнем квизе:\nВремя проведения: %s\n", time)
 	if chatMessageHandler.QuizManager.QuizAlreadyWas {
@@ -533,11 +568,10 @@ func handleShowQuizInfo(c tele.Context, chatMessageHandler *ChatMessageHandler)
 	} else {
 		text = text + "Квиза сегодня ещё не было\n"
 	}
-	answer := chatMessageHandler.QuizManager.TodayQuiz.SongName
-	if chatMessageHandler.QuizManager.TodayQuiz.IsClip {
-		text = text + fmt.Sprintf("Сегодня кадр из клипа\nОтвет: %s", answer)
-	} else {
-		text = text + fmt.Sprintf("Сегодня цитата из песни\nОтвет: %s", answer)
+	todayQuiz := chatMessageHandler.QuizManager.TodayQuiz
+	text = text + fmt.Sprintf("Сегодня %s\nОтвет: %s", activities.GetQuizType(todayQuiz.Kind).Title(), todayQuiz.Answer)
+	if todayQuiz.Answer != todayQuiz.SongName {
+		text = text + fmt.Sprintf("\nПесня: %s", todayQuiz.SongName)
 	}
 	return c.Send(text)
 }
@@ -571,6 +605,10 @@ func handleUnwarn(c tele.Context, chatMessageHandler *ChatMessageHandler) error
 		}
 	}
 
+	if err := chatMessageHandler.Rep.RevertLatestAction(replyToID, []string{admins.ActionWarn}); err != nil {
+		log.Printf("Failed to mark warn of %d as reverted: %v", replyToID, err)
+	}
+
 	text := fmt.Sprintf("%s лишается нажитого непосильным трудом предупреждения. Это надо было серьезно разозлить админа!", chatMsg.ReplyToAppeal())
 	return messages.ReplyToOriginalMessage(c, text, chatMsg.ThreadID())
 }
@@ -580,31 +618,6 @@ func handleCondemn(c tele.Context, chatMessageHandler *ChatMessageHandler) error
 	return messages.ReplyToOriginalMessage(c, text, chatMessageHandler.ChatMessage.ThreadID())
 }
 
-func handlePromoteAdmin(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
-	msg := chatMessageHandler.ChatMessage
-	if msg == nil {
-		return errors.New("chat message is nil")
-	}
-	parts := strings.Split(msg.Text(), " ")
-	if len(parts) != 2 {
-		c.Reply("Неправильный формат команды (len)")
-		return fmt.Errorf("got unexpected len %d, expected 2", len(parts))
-	}
-	idStr := parts[1]
-	id, err := strconv.Atoi(idStr)
-	if err != nil {
-		c.Reply("Неправильный формат команды (Atoi)")
-		return fmt.Errorf("couldn't convert ID to int, %w", err)
-	}
-	id64 := int64(id)
-	err = chatMessageHandler.Rep.PromoteAdmin(id64, "+")
-	if err != nil {
-		c.Reply("Внутренняя ошибка базы данных")
-		return fmt.Errorf("couldn't promote admin %d: %w", id64, err)
-	}
-	return c.Reply(fmt.Sprintf("Успешно продвинули админа %d", id64))
-}
-
 func handleHoroscope(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 	chatMsg := chatMessageHandler.ChatMessage
 	if chatMsg == nil {
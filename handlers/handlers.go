@@ -3,7 +3,9 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"saxbot/activities"
 	"saxbot/admins"
+	"saxbot/database"
 	"saxbot/messages"
 	textcases "saxbot/text_cases"
 	"slices"
@@ -29,6 +31,9 @@ func HandleChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandler) e
 		return nil
 	}
 
+	chatMessageHandler.Messages.Add(msg)
+	archiveMessage(chatMessageHandler, msg)
+
 	// Инициализируем структуру ChatMessage
 	chatMessage, err := initChatMessage(c, chatMessageHandler)
 	if err != nil {
@@ -56,6 +61,21 @@ func HandleChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandler) e
 		return nil
 	}
 
+	if !chatMessage.ChatAdmin() && checkSpam(chatMessageHandler, c.Message(), userData) {
+		return nil
+	}
+
+	// Рестрикт "без ссылок" Telegram не умеет, такие сообщения удаляет бот
+	if userData.Status == "restricted" && userData.RestrictPreset == admins.RestrictLinks && admins.HasLinks(c.Message()) {
+		chatMessageHandler.Bot.Delete(c.Message())
+		return nil
+	}
+
+	// Новичкам на карантине нельзя ссылки и пересылки
+	if !chatMessage.ChatAdmin() && checkQuarantine(chatMessageHandler, c, userData) {
+		return nil
+	}
+
 	if userData.Status == "banned" {
 		if c.Message().OriginalSender != nil || c.Message().OriginalChat != nil {
 			log.Printf("Получено пересланное сообщение от забаненного пользователя %d, автоматический разбан не выполняется", chatMessage.Sender().ID)
@@ -72,14 +92,19 @@ func HandleChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandler) e
 	// Обновляем счетчик сообщений
 	chatMessageHandler.Rep.UpdateUserMessageCount(userData.UserID, 1)
 
-	// Определяем, является ли отправитель админом или победителем
+	// Определяем, является ли отправитель админом или владельцем временной роли
 	isAdmin := chatMessageHandler.Rep.IsAdmin(userData.UserID)
-	isWinnerOnly := chatMessage.IsWinner() && !isAdmin && !chatMessage.ChatAdmin()
-	canUseAdminCommands := isAdmin || chatMessage.IsWinner() || chatMessage.ChatAdmin()
+	isTempRoleOnly := !isAdmin && !chatMessage.ChatAdmin()
+	canUseAdminCommands := isAdmin || chatMessage.ChatAdmin() || len(chatMessage.Permissions()) > 0
+
+	// Сообщение админа в чате засчитывается как ответ на недавний вызов админов
+	if isAdmin || chatMessage.ChatAdmin() {
+		respondToReports(chatMessageHandler)
+	}
 
 	// Маршрутизируем в соответствующий обработчик
 	if canUseAdminCommands {
-		return handleAdminChatMessage(c, chatMessageHandler, isWinnerOnly)
+		return handleAdminChatMessage(c, chatMessageHandler, isTempRoleOnly)
 	} else {
 		return handleUserChatMessage(c, chatMessageHandler)
 	}
@@ -103,13 +128,13 @@ func HandlePrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHandler
 		return nil
 	}
 
-	// Определяем, является ли отправитель админом или победителем
+	// Определяем, является ли отправитель админом или владельцем временной роли
 	isAdmin := chatMessageHandler.Rep.IsAdmin(userData.UserID)
-	isWinnerOnly := chatMessage.IsWinner() && !isAdmin && !chatMessage.ChatAdmin()
-	canUseAdminCommands := isAdmin || chatMessage.IsWinner() || chatMessage.ChatAdmin()
+	isTempRoleOnly := !isAdmin && !chatMessage.ChatAdmin()
+	canUseAdminCommands := isAdmin || chatMessage.ChatAdmin() || len(chatMessage.Permissions()) > 0
 
 	if canUseAdminCommands {
-		return handleAdminPrivateMessage(c, chatMessageHandler, isWinnerOnly)
+		return handleAdminPrivateMessage(c, chatMessageHandler, isTempRoleOnly)
 	} else {
 		return handleUserPrivateMessage(c, chatMessageHandler)
 	}
@@ -156,7 +181,28 @@ func HandleUserJoined(c tele.Context, chatMessageHandler *ChatMessageHandler) er
 		log.Printf("Failed to get user data: %v", err)
 		return nil
 	}
+
+	// Пользователь вернулся в чат - снова участвует в сверке и размуте по таймеру
+	if userData.LeftChat {
+		userData.LeftChat = false
+		if err := chatMessageHandler.Rep.SetUserLeftChat(joinedUser.ID, false); err != nil {
+			log.Printf("Failed to reset left_chat for user %d: %v", joinedUser.ID, err)
+		}
+	}
+
+	// Пользователей из блоклиста баним сразу, без приветствия
+	if entry, err := chatMessageHandler.Rep.GetBlocklistEntry(joinedUser.ID); err != nil {
+		log.Printf("Failed to check blocklist for user %d: %v", joinedUser.ID, err)
+	} else if entry != nil {
+		log.Printf("User %d is blocklisted (reason: %s, source: %s), banning", joinedUser.ID, entry.Reason, entry.Source)
+		chatMember := &tele.ChatMember{User: joinedUser, Role: tele.Member}
+		admins.BanUser(chatMessageHandler.Bot, c.Message().Chat, chatMember, chatMessageHandler.Rep)
+		chatMessageHandler.Bot.Delete(c.Message())
+		return nil
+	}
+
 	if userData.Username != joinedUser.Username || userData.FirstName != joinedUser.FirstName {
+		chatMessageHandler.Rep.RecordUsernameChange(joinedUser.ID, userData.Username, userData.FirstName, joinedUser.Username, joinedUser.FirstName)
 		userData.Username = joinedUser.Username
 		userData.FirstName = joinedUser.FirstName
 		if err := chatMessageHandler.Rep.SaveUser(&userData); err != nil {
@@ -167,51 +213,15 @@ func HandleUserJoined(c tele.Context, chatMessageHandler *ChatMessageHandler) er
 	// Сохраняем оригинальный статус до изменений
 	originalStatus := userData.Status
 
-	appeal := "@" + joinedUser.Username
-	if appeal == "@" {
-		appeal = joinedUser.FirstName
-	}
-
 	// Проверяем, является ли пользователь новым (статус "active") или уже был замучен/рестриктнут/забанен ранее
 	isNewUser := (originalStatus == "active" || originalStatus == "new_user")
 
 	if isNewUser {
-		// Мутим нового пользователя
-		userData.Status = "new_user"
-		if err := chatMessageHandler.Rep.SaveUser(&userData); err != nil {
-			log.Printf("Failed to save new_user status for joined user %d: %v", joinedUser.ID, err)
-		}
-
-		// Ограничиваем права пользователя в чате
-		chatMember := &tele.ChatMember{
-			User: joinedUser,
-			Role: tele.Member,
-			Rights: tele.Rights{
-				CanSendMessages: false,
-			},
-		}
-		if err := chatMessageHandler.Bot.Restrict(c.Message().Chat, chatMember); err != nil {
-			log.Printf("Failed to restrict user %d: %v", joinedUser.ID, err)
-		}
-
-		// Сохраняем State пользователя как нового пользователя
-		chatMessageHandler.SetUserState(joinedUser.ID, "new_user")
-
-		// Показываем кнопку для размута
-		menu := &tele.ReplyMarkup{ResizeKeyboard: true}
-		btnJoin := menu.Data("Я не бот!", "join")
-		menu.Inline(menu.Row(btnJoin))
-		opts := &tele.SendOptions{
-			ReplyMarkup: menu,
-			ThreadID:    c.Message().ThreadID,
-		}
-
-		msg, err := chatMessageHandler.Bot.Reply(c.Message(), textcases.GetUserJoinedMessage(appeal), opts)
-		if err != nil {
-			log.Printf("Failed to send user joined message: %v", err)
+		// Подозрительные профили кикаем или задерживаем до решения админа, приветствие не показываем
+		if screenJoinedUser(chatMessageHandler, c.Message().Chat, joinedUser, &userData) {
 			return nil
 		}
-		go autokick(chatMember, chatMessageHandler, c, msg)
+		welcomeNewUser(chatMessageHandler, c.Message().Chat, joinedUser, &userData, c.Message())
 		return nil
 	} else {
 		// Пользователь был замучен/рестриктнут/забанен ранее
@@ -228,15 +238,10 @@ func HandleUserJoined(c tele.Context, chatMessageHandler *ChatMessageHandler) er
 				CanSendMessages: false,
 			}
 		case "restricted":
-			// Ограничиваем отправку медиа
-			chatMember.Rights = tele.Rights{
-				CanSendMessages:  true,
-				CanSendMedia:     false,
-				CanSendAudios:    false,
-				CanSendVideos:    false,
-				CanSendPhotos:    false,
-				CanSendDocuments: false,
-				CanSendOther:     false,
+			// Забираем права по пресету рестрикта до конца его срока
+			chatMember.Rights = admins.RestrictionRights(userData.RestrictPreset)
+			if userData.RestrictedUntil != nil {
+				chatMember.RestrictedUntil = userData.RestrictedUntil.Unix()
 			}
 		case "banned":
 			// Бан - не применяем ограничения через Restrict, так как пользователь забанен
@@ -261,6 +266,39 @@ func HandleUserJoined(c tele.Context, chatMessageHandler *ChatMessageHandler) er
 	}
 }
 
+// HandleChatMedia запоминает медиа-сообщения разрешенных чатов для зачистки и архива и проверяет их антиспамом
+func HandleChatMedia(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	msg := c.Message()
+	if msg == nil || msg.Chat.Type == tele.ChatPrivate || !slices.Contains(chatMessageHandler.AllowedChats, msg.Chat.ID) {
+		return nil
+	}
+	chatMessageHandler.Messages.Add(msg)
+	archiveMessage(chatMessageHandler, msg)
+
+	if msg.Sender != nil && msg.SenderChat == nil {
+		userData, err := chatMessageHandler.Rep.GetUser(msg.Sender.ID)
+		if err != nil {
+			log.Printf("Failed to get user %d for spam check: %v", msg.Sender.ID, err)
+			return nil
+		}
+		checkSpam(chatMessageHandler, msg, &userData)
+	}
+	return nil
+}
+
+// HandleUserLeft отмечает вышедших из чата пользователей, чтобы сверка и размут по таймеру их пропускали
+func HandleUserLeft(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	leftUser := c.Message().UserLeft
+	if leftUser == nil || c.Message().Chat.ID != chatMessageHandler.QuizManager.QuizChatID {
+		return nil
+	}
+	log.Printf("User %d left chat %d", leftUser.ID, c.Message().Chat.ID)
+	if err := chatMessageHandler.Rep.SetUserLeftChat(leftUser.ID, true); err != nil {
+		log.Printf("Failed to mark user %d as left: %v", leftUser.ID, err)
+	}
+	return nil
+}
+
 // HandleCallback обрабатывает колбэки от инлайн-кнопок
 func HandleCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 	callback := c.Callback()
@@ -295,8 +333,19 @@ func HandleCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) erro
 			})
 		}
 
-		// Размучиваем пользователя
+		// Размучиваем пользователя. Тех, кто пришел впервые, отправляем на карантин,
+		// а вернувшимся до конца карантина он продолжается с того же места
+		quarantine := quarantineEnabled(chatMessageHandler) && (userData.VerifiedAt == nil || userData.Quarantined)
+		if quarantine && !userData.Quarantined {
+			admins.StartQuarantine(&userData, chatMessageHandler.Quarantine.Hours)
+		} else if !quarantine {
+			// Карантин выключили, пока пользователя не было в чате
+			userData.Quarantined = false
+			userData.QuarantineUntil = nil
+		}
+		now := time.Now()
 		userData.Status = "active"
+		userData.VerifiedAt = &now
 		if err := chatMessageHandler.Rep.SaveUser(&userData); err != nil {
 			log.Printf("Failed to save active status for user %d: %v", userID, err)
 			return c.Respond(&tele.CallbackResponse{
@@ -307,17 +356,12 @@ func HandleCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) erro
 
 		// Восстанавливаем права пользователя в чате
 		chatMember := &tele.ChatMember{
-			User: callback.Sender,
-			Role: tele.Member,
-			Rights: tele.Rights{
-				CanSendMessages:  true,
-				CanSendMedia:     true,
-				CanSendAudios:    true,
-				CanSendVideos:    true,
-				CanSendPhotos:    true,
-				CanSendDocuments: true,
-				CanSendOther:     true,
-			},
+			User:   callback.Sender,
+			Role:   tele.Member,
+			Rights: admins.FullRights(),
+		}
+		if quarantine {
+			chatMember.Rights = admins.QuarantineRights()
 		}
 		if err := chatMessageHandler.Bot.Restrict(c.Chat(), chatMember); err != nil {
 			log.Printf("Failed to unrestrict user %d: %v", userID, err)
@@ -333,6 +377,12 @@ func HandleCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) erro
 			chatMessageHandler.Bot.EditReplyMarkup(callback.Message, nil)
 		}
 
+		if quarantine {
+			return c.Respond(&tele.CallbackResponse{
+				Text:      fmt.Sprintf("Добро пожаловать! Теперь ты можешь писать в чат, а медиа, ссылки и пересылки откроются через %s.", quarantineTerms(chatMessageHandler)),
+				ShowAlert: true,
+			})
+		}
 		return c.Respond(&tele.CallbackResponse{
 			Text:      "Добро пожаловать! Теперь ты можешь писать в чат.",
 			ShowAlert: false,
@@ -354,13 +404,30 @@ func HandleCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) erro
 
 	case "main_menu":
 		userID := callback.Sender.ID
-		if slices.Contains(chatMessageHandler.AdminsList, userID) {
+		if chatMessageHandler.Rep.IsAdmin(userID) {
 			return handleAdminMenu(c)
 		} else {
 			return handleUserMenu(c)
 		}
 	}
 
+	if strings.HasPrefix(callbackData, "join_ok_") || strings.HasPrefix(callbackData, "join_no_") {
+		return handleJoinCallback(c, chatMessageHandler, callbackData)
+	}
+
+	if strings.HasPrefix(callbackData, "screen_ok_") || strings.HasPrefix(callbackData, "screen_kick_") {
+		return handleScreeningCallback(c, chatMessageHandler, callbackData)
+	}
+
+	if strings.HasPrefix(callbackData, "vote_") {
+		return handleVoteCallback(c, chatMessageHandler, callbackData)
+	}
+
+	// Панель приостановленного админа: restore_admin_<id>, revert_actions_<id> (только главный админ)
+	if strings.HasPrefix(callbackData, "restore_admin_") || strings.HasPrefix(callbackData, "revert_actions_") {
+		return handleSafetyCallback(c, chatMessageHandler, callbackData)
+	}
+
 	// Выбор альбома: album_1 .. album_5 (только ЛС + админ)
 	if strings.HasPrefix(callbackData, "album_") {
 		albumID, err := strconv.Atoi(strings.TrimPrefix(callbackData, "album_"))
@@ -387,19 +454,71 @@ func HandleCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) erro
 	return nil
 }
 
-func autokick(user *tele.ChatMember, chatMessageHandler *ChatMessageHandler, c tele.Context, welcomeMessage *tele.Message) error {
+// welcomeNewUser мутит нового пользователя и показывает приветствие с кнопкой "Я не бот!".
+// joinMessage - сервисное сообщение о входе, nil если пользователя впустил админ после проверки профиля
+func welcomeNewUser(chatMessageHandler *ChatMessageHandler, chat *tele.Chat, joinedUser *tele.User, userData *database.User, joinMessage *tele.Message) {
+	appeal := "@" + joinedUser.Username
+	if appeal == "@" {
+		appeal = joinedUser.FirstName
+	}
+
+	// Мутим нового пользователя
+	userData.Status = "new_user"
+	if err := chatMessageHandler.Rep.SaveUser(userData); err != nil {
+		log.Printf("Failed to save new_user status for joined user %d: %v", joinedUser.ID, err)
+	}
+
+	// Ограничиваем права пользователя в чате
+	chatMember := &tele.ChatMember{
+		User: joinedUser,
+		Role: tele.Member,
+		Rights: tele.Rights{
+			CanSendMessages: false,
+		},
+	}
+	if err := chatMessageHandler.Bot.Restrict(chat, chatMember); err != nil {
+		log.Printf("Failed to restrict user %d: %v", joinedUser.ID, err)
+	}
+
+	// Сохраняем State пользователя как нового пользователя
+	chatMessageHandler.SetUserState(joinedUser.ID, "new_user")
+
+	// Показываем кнопку для размута
+	menu := &tele.ReplyMarkup{ResizeKeyboard: true}
+	btnJoin := menu.Data("Я не бот!", "join")
+	menu.Inline(menu.Row(btnJoin))
+	opts := &tele.SendOptions{ReplyMarkup: menu}
+
+	var msg *tele.Message
+	var err error
+	if joinMessage != nil {
+		opts.ThreadID = joinMessage.ThreadID
+		msg, err = chatMessageHandler.Bot.Reply(joinMessage, textcases.GetUserJoinedMessage(appeal), opts)
+	} else {
+		msg, err = chatMessageHandler.Bot.Send(chat, textcases.GetUserJoinedMessage(appeal), opts)
+	}
+	if err != nil {
+		log.Printf("Failed to send user joined message: %v", err)
+		return
+	}
+	go autokick(chatMember, chatMessageHandler, chat, joinMessage, msg)
+}
+
+func autokick(user *tele.ChatMember, chatMessageHandler *ChatMessageHandler, chat *tele.Chat, joinMessage *tele.Message, welcomeMessage *tele.Message) error {
 	time.Sleep(5 * time.Minute)
 	userData, err := chatMessageHandler.Rep.GetUser(user.User.ID)
 	if err != nil {
 		return fmt.Errorf("failed to get user %d: %w", user.User.ID, err)
 	}
 	if userData.Status == "new_user" {
-		err = admins.KickUser(chatMessageHandler.Bot, c.Chat(), user)
+		err = admins.KickUser(chatMessageHandler.Bot, chat, user)
 		if err != nil {
 			return fmt.Errorf("failed to kick user %d: %w", userData.UserID, err)
 		}
 	}
-	chatMessageHandler.Bot.Delete(c.Message())
+	if joinMessage != nil {
+		chatMessageHandler.Bot.Delete(joinMessage)
+	}
 	chatMessageHandler.Bot.Delete(welcomeMessage)
 	return nil
 }
@@ -409,54 +528,78 @@ func ManageRunningQuiz(c tele.Context, chatMessageHandler *ChatMessageHandler) {
 	log.Printf("Quiz running: %v", quizRunning)
 	log.Println(c.Message().Text)
 	log.Println(todayQuiz.SongName)
-	if strings.EqualFold(c.Message().Text, todayQuiz.SongName) {
-		if slices.Contains(chatMessageHandler.AdminsList, c.Message().Sender.ID) || chatMessageHandler.ChatMessage.chatAdmin {
-			messages.ReplyMessage(c, "Ты и так уже админ, дружок-пирожок. Дай выиграть тем, кто пока ещё нет", c.Message().ThreadID)
-			return
-		}
-		chatMessageHandler.QuizManager.SetQuizRunning(false)
-		chatMessageHandler.QuizManager.SetQuizAlreadyWas(true)
-		chatMessageHandler.Rep.SetQuizAlreadyWas()
-		var winnerTitle string
+	// Ответ сравнивается без учета регистра, знаков препинания и пары опечаток, с учетом прозвищ песни.
+	// Как именно проверять ответ, решает вид квиза
+	quizType := activities.GetQuizType(todayQuiz.Kind)
+	switch quizType.Check(c.Message().Text, todayQuiz, chatMessageHandler.QuizManager.Aliases()) {
+	case textcases.AnswerClose:
+		messages.ReplyMessage(c, "Почти!", c.Message().ThreadID)
+		return
+	case textcases.AnswerWrong:
+		return
+	}
+	if chatMessageHandler.Rep.IsAdmin(c.Message().Sender.ID) || chatMessageHandler.ChatMessage.chatAdmin {
+		messages.ReplyMessage(c, "Ты и так уже админ, дружок-пирожок. Дай выиграть тем, кто пока ещё нет", c.Message().ThreadID)
+		return
+	}
+	// Квиз мог завершиться по сроку или другим ответом, пока сообщение обрабатывалось
+	if !chatMessageHandler.QuizManager.FinishQuiz() {
+		return
+	}
+	chatMessageHandler.Rep.SetQuizAlreadyWas()
+	var winnerTitle string
 
-		winnerTitle = textcases.GetRandomTitle()
+	winnerTitle = textcases.GetRandomTitle()
 
-		audio, err := chatMessageHandler.Rep.GetAudioByName(todayQuiz.SongName)
-		if err != nil {
-			messages.ReplyMessage(c, fmt.Sprintf("Правильно! Песня: %s", todayQuiz.SongName), c.Message().ThreadID)
-		} else {
-			caption := fmt.Sprintf("Правильно! Песня: %s", todayQuiz.SongName)
-			if audio.ClipURL != "" {
-				caption = fmt.Sprintf("%s\n\n<b><a href=\"%s\">Смотреть клип</a></b>", caption, audio.ClipURL)
-			}
-			audio := &tele.Audio{
-				File: tele.File{
-					FileID: audio.FileID,
-				},
-				Caption: caption,
-			}
-			opts := &tele.SendOptions{
-				ParseMode: tele.ModeHTML,
-				ThreadID:  c.Message().ThreadID,
-			}
-			c.Reply(audio, opts)
+	audio, err := chatMessageHandler.Rep.GetAudioByName(todayQuiz.SongName)
+	if err != nil {
+		messages.ReplyMessage(c, fmt.Sprintf("Правильно! %s", quizType.Reveal(todayQuiz)), c.Message().ThreadID)
+	} else {
+		caption := fmt.Sprintf("Правильно! %s", quizType.Reveal(todayQuiz))
+		if audio.ClipURL != "" {
+			caption = fmt.Sprintf("%s\n\n<b><a href=\"%s\">Смотреть клип</a></b>", caption, audio.ClipURL)
+		}
+		audio := &tele.Audio{
+			File: tele.File{
+				FileID: audio.FileID,
+			},
+			Caption: caption,
+		}
+		opts := &tele.SendOptions{
+			ParseMode: tele.ModeHTML,
+			ThreadID:  c.Message().ThreadID,
 		}
-		time.Sleep(30 * time.Millisecond)
-		messages.ReplyMessage(c, fmt.Sprintf("Поздравляем, %s! Ты победил и получил титул %s до следующего квиза!", chatMessageHandler.ChatMessage.appeal, winnerTitle), c.Message().ThreadID)
-		chatMember := &tele.ChatMember{User: c.Message().Sender, Role: tele.Member}
-		admins.SetPref(chatMessageHandler.Bot, c.Chat(), chatMember, winnerTitle)
-		quiz, err := chatMessageHandler.Rep.GetLastCompletedQuiz()
+		c.Reply(audio, opts)
+	}
+	time.Sleep(30 * time.Millisecond)
+	messages.ReplyMessage(c, fmt.Sprintf("Поздравляем, %s! Ты победил и получил титул %s до следующего квиза!", chatMessageHandler.ChatMessage.appeal, winnerTitle), c.Message().ThreadID)
+	// Победитель получает временную роль с правом предупреждать и титулом до следующего квиза
+	winnerRole := &database.TempRole{
+		UserID:      c.Message().Sender.ID,
+		ChatID:      c.Chat().ID,
+		Kind:        database.TempRoleQuizWinner,
+		Permissions: database.PermWarn,
+		Tag:         winnerTitle,
+	}
+	if err := admins.GrantTempRole(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, winnerRole, chatMessageHandler.Rep); err != nil {
+		log.Printf("failed to grant quiz winner role to user %d: %v", c.Message().Sender.ID, err)
+	}
+	quiz, err := chatMessageHandler.Rep.GetLastCompletedQuiz()
+	if err != nil {
+		log.Printf("failed to get last completed quiz: %v", err)
+	} else if quiz != nil {
+		err = chatMessageHandler.Rep.SetQuizWinner(quiz.ID, c.Message().Sender.ID)
 		if err != nil {
-			log.Printf("failed to get last completed quiz: %v", err)
-		} else if quiz != nil {
-			err = chatMessageHandler.Rep.SetQuizWinner(quiz.ID, c.Message().Sender.ID)
-			if err != nil {
-				log.Printf("failed to set user %d as a quiz winner %v", c.Message().Sender.ID, err)
-			}
+			log.Printf("failed to set user %d as a quiz winner %v", c.Message().Sender.ID, err)
+		}
+		// Очки за победу, скорость ответа и серию побед идут в таблицу сезона
+		speedBonus := activities.SpeedBonus(time.Since(chatMessageHandler.QuizManager.StartedAt()))
+		if total, details := activities.AwardQuizWin(chatMessageHandler.Rep, quiz.ID, c.Message().Sender.ID, speedBonus); total > 0 {
+			messages.ReplyMessage(c, fmt.Sprintf("Очки в сезоне: +%d (%s). Таблица - по команде \"топ квиза\"", total, details), c.Message().ThreadID)
 		}
-		// Обновляем isLastQuizClip после завершения квиза для правильного чередования
-		chatMessageHandler.QuizManager.SetIsLastQuizClip(todayQuiz.IsClip)
 	}
+	// Обновляем вид последнего квиза после завершения квиза для правильного чередования
+	chatMessageHandler.QuizManager.SetLastQuizKind(todayQuiz.Kind)
 }
 
 func HandleChannelPost(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
@@ -3,6 +3,7 @@ package handlers
 import (
 	"fmt"
 	"log"
+	"saxbot/admins"
 	"saxbot/database"
 	"saxbot/messages"
 	textcases "saxbot/text_cases"
@@ -75,10 +76,32 @@ func handleAdminMenu(c tele.Context) error {
 	return c.Reply(text, &tele.SendOptions{ReplyMarkup: menu})
 }
 
+// isStaffCallback проверяет, что кнопку со служебной информацией нажал админ
+func isStaffCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) bool {
+	userID := c.Callback().Sender.ID
+	return userID == chatMessageHandler.MainAdminID || chatMessageHandler.Rep.IsAdmin(userID)
+}
+
+// usersNotes возвращает заметки о пользователях из списка
+func usersNotes(chatMessageHandler *ChatMessageHandler, users []database.User) map[int64][]database.UserNote {
+	ids := make([]int64, 0, len(users))
+	for _, user := range users {
+		ids = append(ids, user.UserID)
+	}
+	notes, err := chatMessageHandler.Rep.GetUsersNotes(ids)
+	if err != nil {
+		log.Printf("failed to get notes for users list: %v", err)
+	}
+	return notes
+}
+
 func handleMutedCallback(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
 	if err := c.Respond(); err != nil {
 		return err
 	}
+	if !isStaffCallback(c, chatMessageHandler) {
+		return nil
+	}
 	users, err := chatMessageHandler.Rep.GetAllMutedUsers()
 	if err != nil {
 		return c.Send("Произошла внутренняя ошибка базы данных. Попробуйте ещё раз")
@@ -87,12 +110,14 @@ func handleMutedCallback(c tele.Context, chatMessageHandler *ChatMessageHandler)
 		return c.Send("В базе данных сейчас нет пользователей в муте")
 	} else {
 		text := "Вот список пользователей в муте. Пользователя можно размутить досрочно командой \"Размут [id]\":\n"
+		notes := usersNotes(chatMessageHandler, users)
 		for count, user := range users {
 			mutedUntilStr := "не установлено"
 			if !user.MutedUntil.IsZero() {
 				mutedUntilStr = user.MutedUntil.In(database.MoscowTZ).Format("2006-01-02 15:04:05")
 			}
 			text = text + fmt.Sprintf("%d. @%s, имя: %s, id: %d, время размута %s\n", count+1, user.Username, user.FirstName, user.UserID, mutedUntilStr)
+			text = text + formatNotes(chatMessageHandler, notes[user.UserID], "    📝 ")
 		}
 		return c.Send(text)
 	}
@@ -102,6 +127,9 @@ func handleRestrictedCallback(c tele.Context, chatMessageHandler *ChatMessageHan
 	if err := c.Respond(); err != nil {
 		return err
 	}
+	if !isStaffCallback(c, chatMessageHandler) {
+		return nil
+	}
 	users, err := chatMessageHandler.Rep.GetAllRestrictedUsers()
 	if err != nil {
 		return c.Send("Произошла внутренняя ошибка базы данных. Попробуйте ещё раз")
@@ -110,8 +138,14 @@ func handleRestrictedCallback(c tele.Context, chatMessageHandler *ChatMessageHan
 		return c.Send("В базе данных сейчас нет рестриктнутых пользователей")
 	} else {
 		text := "Вот список рестриктнутых пользователей. С пользователя можно снять ограничения командой \"Размут [id]\":\n"
+		notes := usersNotes(chatMessageHandler, users)
 		for count, user := range users {
-			text = text + fmt.Sprintf("%d. @%s, имя: %s, id: %d\n", count+1, user.Username, user.FirstName, user.UserID)
+			until := "бессрочно"
+			if user.RestrictedUntil != nil {
+				until = "до " + user.RestrictedUntil.In(database.MoscowTZ).Format("2006-01-02 15:04:05")
+			}
+			text = text + fmt.Sprintf("%d. @%s, имя: %s, id: %d, %s, %s\n", count+1, user.Username, user.FirstName, user.UserID, admins.RestrictPresetTitle(user.RestrictPreset), until)
+			text = text + formatNotes(chatMessageHandler, notes[user.UserID], "    📝 ")
 		}
 		return c.Send(text)
 	}
@@ -0,0 +1,237 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Состояние лички заявителя, который еще не ответил на вопрос анкеты
+const joinAnswerState = "join_answer"
+
+const joinQuestion = "Привет! Это бот чата Nick Sax. Чтобы попасть в чат, ответь на вопрос: какая твоя любимая песня Nick Sax? Напиши название одним сообщением."
+
+// HandleJoinRequest обрабатывает заявку на вступление: задает заявителю вопрос анкеты в личке,
+// а если написать ему не получилось, сразу отправляет заявку главному админу
+func HandleJoinRequest(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	joinRequest := c.ChatJoinRequest()
+	if joinRequest == nil || joinRequest.Sender == nil || !slices.Contains(chatMessageHandler.AllowedChats, joinRequest.Chat.ID) {
+		return nil
+	}
+	user := joinRequest.Sender
+	log.Printf("User %d requested to join chat %d", user.ID, joinRequest.Chat.ID)
+
+	// Пользователей из блоклиста отклоняем без вопросов
+	if chatMessageHandler.Rep.IsBlocklisted(user.ID) {
+		log.Printf("User %d is blocklisted, declining join request", user.ID)
+		if err := chatMessageHandler.Bot.DeclineJoinRequest(joinRequest.Chat, user); err != nil {
+			log.Printf("Failed to decline join request of user %d: %v", user.ID, err)
+		}
+		return nil
+	}
+
+	request := &database.JoinRequest{
+		ChatID:    joinRequest.Chat.ID,
+		UserID:    user.ID,
+		FirstName: user.FirstName,
+		Username:  user.Username,
+		Bio:       joinRequest.Bio,
+		Status:    database.JoinAsking,
+	}
+	if err := chatMessageHandler.Rep.CreateJoinRequest(request); err != nil {
+		log.Printf("Failed to save join request: %v", err)
+		return nil
+	}
+
+	// Писать заявителю можно по UserChatID в течение 5 минут после заявки
+	recipient := &tele.Chat{ID: joinRequest.UserChatID}
+	if joinRequest.UserChatID == 0 {
+		recipient = &tele.Chat{ID: user.ID}
+	}
+	if _, err := chatMessageHandler.Bot.Send(recipient, joinQuestion); err != nil {
+		log.Printf("Failed to send join question to user %d: %v", user.ID, err)
+		queueJoinRequest(chatMessageHandler, request, "Не удалось задать вопрос в личке")
+		return nil
+	}
+	chatMessageHandler.SetUserState(user.ID, joinAnswerState)
+	return nil
+}
+
+// RestoreJoinStates после перезапуска возвращает состояние ожидания ответа заявителям,
+// которые еще не ответили на вопрос анкеты
+func RestoreJoinStates(chatMessageHandler *ChatMessageHandler) {
+	requests, err := chatMessageHandler.Rep.GetJoinRequestsByStatus(database.JoinAsking)
+	if err != nil {
+		log.Printf("failed to restore join request states: %v", err)
+		return
+	}
+	for _, request := range requests {
+		chatMessageHandler.SetUserState(request.UserID, joinAnswerState)
+	}
+}
+
+// handleJoinAnswer проверяет ответ на вопрос анкеты: правильный ответ принимает заявку (если включен
+// автоприем), в остальных случаях заявка уходит на решение главному админу
+func handleJoinAnswer(c tele.Context, chatMessageHandler *ChatMessageHandler, request *database.JoinRequest) error {
+	chatMessageHandler.SetUserState(request.UserID, "default")
+	request.Answer = strings.TrimSpace(c.Message().Text)
+	if !textcases.IsTrackTitle(request.Answer) || !chatMessageHandler.JoinSettings.AutoApprove {
+		queueJoinRequest(chatMessageHandler, request, "")
+		return c.Send("Спасибо! Заявку посмотрят админы, решение придет сюда.")
+	}
+
+	if err := chatMessageHandler.Bot.ApproveJoinRequest(&tele.Chat{ID: request.ChatID}, &tele.User{ID: request.UserID}); err != nil {
+		// Скорее всего, заявку уже рассмотрели в самом Telegram
+		log.Printf("Failed to approve join request %d: %v", request.ID, err)
+		closeJoinRequest(chatMessageHandler, request, database.JoinExpired, 0)
+		return c.Send("Не получилось принять заявку: похоже, ее уже рассмотрели. Если ты еще не в чате, подай заявку заново.")
+	}
+	closeJoinRequest(chatMessageHandler, request, database.JoinApproved, 0)
+	log.Printf("Join request %d of user %d approved automatically", request.ID, request.UserID)
+	return c.Send("Правильно! Заявка принята, добро пожаловать в чат.")
+}
+
+// queueJoinRequest отправляет заявку главному админу с кнопками "Принять" и "Отклонить"
+func queueJoinRequest(chatMessageHandler *ChatMessageHandler, request *database.JoinRequest, note string) {
+	request.Status = database.JoinPending
+	if chatMessageHandler.MainAdminID == 0 {
+		log.Printf("MAIN_ADMIN_ID is not set, join request %d will wait for timeout", request.ID)
+	} else {
+		menu := &tele.ReplyMarkup{}
+		id := strconv.FormatUint(uint64(request.ID), 10)
+		btnApprove := menu.Data("Принять", "join_ok_"+id)
+		btnDecline := menu.Data("Отклонить", "join_no_"+id)
+		menu.Inline(menu.Row(btnApprove, btnDecline))
+
+		card, err := chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, joinRequestCard(chatMessageHandler, request, note), &tele.SendOptions{ParseMode: tele.ModeHTML, ReplyMarkup: menu})
+		if err != nil {
+			log.Printf("Failed to send join request %d to main admin: %v", request.ID, err)
+		} else {
+			request.AdminMessageID = card.ID
+		}
+	}
+	if err := chatMessageHandler.Rep.SaveJoinRequest(request); err != nil {
+		log.Printf("Failed to queue join request %d: %v", request.ID, err)
+	}
+}
+
+// joinRequestCard собирает карточку заявки для главного админа
+func joinRequestCard(chatMessageHandler *ChatMessageHandler, request *database.JoinRequest, note string) string {
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>Заявка на вступление в чат %d</b>\n", request.ChatID))
+	sb.WriteString(fmt.Sprintf("Имя: %s\n", html.EscapeString(request.FirstName)))
+	if request.Username != "" {
+		sb.WriteString(fmt.Sprintf("Username: @%s\n", html.EscapeString(request.Username)))
+	}
+	sb.WriteString(fmt.Sprintf("Telegram ID: <code>%d</code>\n", request.UserID))
+	if request.Bio != "" {
+		sb.WriteString(fmt.Sprintf("О себе: %s\n", html.EscapeString(request.Bio)))
+	}
+	if request.Answer != "" {
+		verdict := "нет такой песни"
+		if textcases.IsTrackTitle(request.Answer) {
+			verdict = "ответ правильный"
+		}
+		sb.WriteString(fmt.Sprintf("Любимая песня: %s (%s)\n", html.EscapeString(request.Answer), verdict))
+	}
+	if note != "" {
+		sb.WriteString(note + "\n")
+	}
+	sb.WriteString(fmt.Sprintf("\nБез решения заявка будет отклонена через %d мин.", int(chatMessageHandler.JoinSettings.Timeout.Minutes())))
+	return sb.String()
+}
+
+// closeJoinRequest записывает решение по заявке
+func closeJoinRequest(chatMessageHandler *ChatMessageHandler, request *database.JoinRequest, status string, decidedBy int64) {
+	now := time.Now()
+	request.Status = status
+	request.DecidedBy = decidedBy
+	request.DecidedAt = &now
+	if err := chatMessageHandler.Rep.SaveJoinRequest(request); err != nil {
+		log.Printf("Failed to close join request %d: %v", request.ID, err)
+	}
+}
+
+// handleJoinCallback обрабатывает кнопки карточки заявки: join_ok_<id>, join_no_<id>
+func handleJoinCallback(c tele.Context, chatMessageHandler *ChatMessageHandler, callbackData string) error {
+	adminID := c.Callback().Sender.ID
+	if adminID != chatMessageHandler.MainAdminID && !chatMessageHandler.Rep.AdminHasPermission(adminID, database.PermBan) {
+		return c.Respond(&tele.CallbackResponse{Text: "Эта кнопка не для тебя!"})
+	}
+
+	approve := strings.HasPrefix(callbackData, "join_ok_")
+	id, err := strconv.ParseUint(strings.TrimPrefix(strings.TrimPrefix(callbackData, "join_ok_"), "join_no_"), 10, 64)
+	if err != nil {
+		return c.Respond()
+	}
+	request, err := chatMessageHandler.Rep.GetJoinRequest(uint(id))
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "Внутренняя ошибка базы данных", ShowAlert: true})
+	}
+	if request == nil || (request.Status != database.JoinPending && request.Status != database.JoinAsking) {
+		c.Respond(&tele.CallbackResponse{Text: "Заявка уже рассмотрена"})
+		return c.Edit(c.Message().Text + "\n\nЗаявка уже рассмотрена")
+	}
+
+	chat := &tele.Chat{ID: request.ChatID}
+	user := &tele.User{ID: request.UserID}
+	if approve {
+		if err := chatMessageHandler.Bot.ApproveJoinRequest(chat, user); err != nil {
+			log.Printf("Failed to approve join request %d: %v", request.ID, err)
+			closeJoinRequest(chatMessageHandler, request, database.JoinExpired, adminID)
+			c.Respond(&tele.CallbackResponse{Text: "Telegram не принял решение: заявку уже рассмотрели или отозвали", ShowAlert: true})
+			return c.Edit(c.Message().Text + "\n\nЗаявка больше не действует")
+		}
+		closeJoinRequest(chatMessageHandler, request, database.JoinApproved, adminID)
+		chatMessageHandler.Bot.Send(user, "Заявка принята, добро пожаловать в чат!")
+		log.Printf("Admin %d approved join request %d", adminID, request.ID)
+		c.Respond(&tele.CallbackResponse{Text: "Заявка принята"})
+		return c.Edit(c.Message().Text + "\n\nПринята")
+	}
+
+	if err := chatMessageHandler.Bot.DeclineJoinRequest(chat, user); err != nil {
+		log.Printf("Failed to decline join request %d: %v", request.ID, err)
+	}
+	closeJoinRequest(chatMessageHandler, request, database.JoinDeclined, adminID)
+	chatMessageHandler.Bot.Send(user, "К сожалению, заявка в чат отклонена.")
+	log.Printf("Admin %d declined join request %d", adminID, request.ID)
+	c.Respond(&tele.CallbackResponse{Text: "Заявка отклонена"})
+	return c.Edit(c.Message().Text + "\n\nОтклонена")
+}
+
+// ExpireJoinRequests отклоняет заявки, которые не рассмотрели за JOIN_REQUEST_TIMEOUT_MINUTES
+func ExpireJoinRequests(chatMessageHandler *ChatMessageHandler) {
+	requests, err := chatMessageHandler.Rep.GetStaleJoinRequests(time.Now().Add(-chatMessageHandler.JoinSettings.Timeout))
+	if err != nil {
+		log.Printf("failed to get stale join requests: %v", err)
+		return
+	}
+	for i := range requests {
+		request := &requests[i]
+		unanswered := request.Status == database.JoinAsking
+		if err := chatMessageHandler.Bot.DeclineJoinRequest(&tele.Chat{ID: request.ChatID}, &tele.User{ID: request.UserID}); err != nil {
+			log.Printf("failed to decline expired join request %d: %v", request.ID, err)
+		}
+		closeJoinRequest(chatMessageHandler, request, database.JoinExpired, 0)
+		log.Printf("Join request %d of user %d expired", request.ID, request.UserID)
+
+		// Убираем кнопки с карточки, чтобы по ней уже нельзя было принять решение
+		if request.AdminMessageID != 0 {
+			card := &tele.StoredMessage{MessageID: strconv.Itoa(request.AdminMessageID), ChatID: chatMessageHandler.MainAdminID}
+			if _, err := chatMessageHandler.Bot.EditReplyMarkup(card, nil); err != nil {
+				log.Printf("failed to remove buttons from join request card %d: %v", request.ID, err)
+			}
+		}
+		if unanswered {
+			chatMessageHandler.Bot.Send(&tele.User{ID: request.UserID}, "Время на ответ вышло, заявка отклонена. Можно подать ее заново.")
+		}
+	}
+}
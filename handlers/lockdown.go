@@ -0,0 +1,150 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/messages"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Открытие чата в командах закрытия
+const (
+	lockdownOpen      = "open"
+	lockdownOpenMedia = "open_media" // "медиа вкл" снимает только закрытие медиа
+)
+
+// Срок в командах: "1ч", "30 мин", "2 часа", "1д"
+var durationPattern = regexp.MustCompile(`^(\d+)\s*([а-яё]*)$`)
+
+// parseLockdownCommand разбирает команды "закрыть чат [на срок]", "медиа выкл [на срок]", "открыть чат" и "медиа вкл".
+// Возвращает режим закрытия (или lockdownOpen, lockdownOpenMedia) и остаток команды со сроком
+func parseLockdownCommand(text string) (string, string, bool) {
+	switch {
+	case text == "открыть чат":
+		return lockdownOpen, "", true
+	case text == "медиа вкл":
+		return lockdownOpenMedia, "", true
+	case text == "закрыть чат" || strings.HasPrefix(text, "закрыть чат "):
+		return admins.LockdownAll, strings.TrimSpace(strings.TrimPrefix(text, "закрыть чат")), true
+	case text == "медиа выкл" || strings.HasPrefix(text, "медиа выкл "):
+		return admins.LockdownMedia, strings.TrimSpace(strings.TrimPrefix(text, "медиа выкл")), true
+	}
+	return "", "", false
+}
+
+// parseRussianDuration разбирает срок вида "на 1ч", "30 мин", "2 часа", "1 день". Число без единиц - минуты
+func parseRussianDuration(text string) (time.Duration, bool) {
+	text = strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(text), "на "))
+	match := durationPattern.FindStringSubmatch(text)
+	if match == nil {
+		return 0, false
+	}
+	amount, err := strconv.Atoi(match[1])
+	if err != nil || amount <= 0 {
+		return 0, false
+	}
+	switch match[2] {
+	case "", "м", "мин", "минута", "минуту", "минуты", "минут":
+		return time.Duration(amount) * time.Minute, true
+	case "ч", "час", "часа", "часов":
+		return time.Duration(amount) * time.Hour, true
+	case "д", "день", "дня", "дней", "сутки", "суток":
+		return time.Duration(amount) * 24 * time.Hour, true
+	}
+	return 0, false
+}
+
+// Обработка команд закрытия и открытия чата
+func handleLockdown(c tele.Context, chatMessageHandler *ChatMessageHandler, mode string, rest string) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+
+	if mode == lockdownOpenMedia {
+		// "медиа вкл" не должна открывать полностью закрытый чат
+		lockdown, err := chatMessageHandler.Rep.GetLockdown(c.Chat().ID)
+		if err != nil {
+			log.Printf("Failed to get lockdown of chat %d: %v", c.Chat().ID, err)
+			return messages.ReplyMessage(c, "Внутренняя ошибка базы данных. Попробуй еще раз", chatMsg.ThreadID())
+		}
+		if lockdown != nil && lockdown.Mode == admins.LockdownAll {
+			return messages.ReplyMessage(c, "Чат закрыт полностью. Чтобы открыть его, напиши \"открыть чат\"", chatMsg.ThreadID())
+		}
+		mode = lockdownOpen
+	}
+
+	if mode == lockdownOpen {
+		unlocked, err := admins.UnlockChat(chatMessageHandler.Bot, c.Chat(), chatMessageHandler.Rep)
+		if err != nil {
+			log.Printf("Failed to unlock chat %d: %v", c.Chat().ID, err)
+			return messages.ReplyMessage(c, "Не получилось открыть чат. Проверь, что у бота есть право ограничивать участников", chatMsg.ThreadID())
+		}
+		if !unlocked {
+			return messages.ReplyMessage(c, "Чат и так открыт", chatMsg.ThreadID())
+		}
+		log.Printf("Admin %d unlocked chat %d", chatMsg.Sender().ID, c.Chat().ID)
+		announceLockdown(chatMessageHandler, c.Chat(), "Чат снова открыт, права участников восстановлены.")
+		return nil
+	}
+
+	var until *time.Time
+	if rest != "" {
+		duration, ok := parseRussianDuration(rest)
+		if !ok {
+			return messages.ReplyMessage(c, "Не понял срок. Пиши \"закрыть чат на 1ч\" или \"медиа выкл на 30 мин\", без срока чат закроется до команды \"открыть чат\"", chatMsg.ThreadID())
+		}
+		end := time.Now().Add(duration)
+		until = &end
+	}
+
+	if err := admins.LockChat(chatMessageHandler.Bot, c.Chat(), mode, until, chatMsg.Sender().ID, chatMessageHandler.Rep); err != nil {
+		log.Printf("Failed to lock chat %d: %v", c.Chat().ID, err)
+		return messages.ReplyMessage(c, "Не получилось закрыть чат. Проверь, что у бота есть право ограничивать участников", chatMsg.ThreadID())
+	}
+	log.Printf("Admin %d locked chat %d (mode %s)", chatMsg.Sender().ID, c.Chat().ID, mode)
+
+	text := "Чат закрыт админами, писать пока нельзя."
+	if mode == admins.LockdownMedia {
+		text = "Медиа в чате выключены, писать можно только текстом."
+	}
+	if until != nil {
+		text += fmt.Sprintf(" Откроется автоматически %s по Москве.", until.In(database.MoscowTZ).Format("02.01 в 15:04"))
+	} else {
+		text += " Откроется, когда админ напишет \"открыть чат\"."
+	}
+	announceLockdown(chatMessageHandler, c.Chat(), text)
+	return nil
+}
+
+// announceLockdown публикует объявление о закрытии или открытии чата
+func announceLockdown(chatMessageHandler *ChatMessageHandler, chat *tele.Chat, text string) {
+	if _, err := chatMessageHandler.Bot.Send(chat, text); err != nil {
+		log.Printf("Failed to announce lockdown change in chat %d: %v", chat.ID, err)
+	}
+}
+
+// LiftExpiredLockdowns открывает чаты, срок закрытия которых истек (в том числе пока бот был выключен)
+func LiftExpiredLockdowns(chatMessageHandler *ChatMessageHandler) {
+	lockdowns, err := chatMessageHandler.Rep.GetExpiredLockdowns()
+	if err != nil {
+		log.Printf("failed to get expired lockdowns: %v", err)
+		return
+	}
+	for _, lockdown := range lockdowns {
+		chat := &tele.Chat{ID: lockdown.ChatID}
+		if _, err := admins.UnlockChat(chatMessageHandler.Bot, chat, chatMessageHandler.Rep); err != nil {
+			log.Printf("failed to unlock chat %d: %v", lockdown.ChatID, err)
+			continue
+		}
+		log.Printf("Lockdown of chat %d expired, chat unlocked", lockdown.ChatID)
+		announceLockdown(chatMessageHandler, chat, "Время закрытия вышло: чат снова открыт, права участников восстановлены.")
+	}
+}
@@ -0,0 +1,96 @@
+package handlers
+
+import (
+	"slices"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Боты могут удалять только сообщения младше 48 часов
+const deletableAge = 48 * time.Hour
+
+type indexedMessage struct {
+	ID       int
+	UserID   int64
+	ThreadID int
+	SentAt   time.Time
+}
+
+// MessageIndex хранит ID последних сообщений каждого чата для зачистки.
+// На каждый чат хранится не больше limit сообщений, старые вытесняются
+type MessageIndex struct {
+	mu    sync.Mutex
+	limit int
+	chats map[int64][]indexedMessage
+}
+
+func NewMessageIndex(limit int) *MessageIndex {
+	return &MessageIndex{
+		limit: limit,
+		chats: make(map[int64][]indexedMessage),
+	}
+}
+
+// Add запоминает сообщение пользователя или канала
+func (i *MessageIndex) Add(msg *tele.Message) {
+	if msg == nil || msg.Chat == nil || i.limit <= 0 {
+		return
+	}
+	var userID int64
+	switch {
+	case msg.SenderChat != nil:
+		userID = msg.SenderChat.ID
+	case msg.Sender != nil:
+		userID = msg.Sender.ID
+	}
+
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	messages := append(i.chats[msg.Chat.ID], indexedMessage{
+		ID:       msg.ID,
+		UserID:   userID,
+		ThreadID: msg.ThreadID,
+		SentAt:   msg.Time(),
+	})
+	if len(messages) > i.limit {
+		messages = slices.Clone(messages[len(messages)-i.limit:])
+	}
+	i.chats[msg.Chat.ID] = messages
+}
+
+// UserMessages возвращает ID последних (не больше limit) сообщений пользователя в чате, которые еще можно удалить
+func (i *MessageIndex) UserMessages(chatID int64, userID int64, limit int) []int {
+	return i.collect(chatID, limit, func(m indexedMessage) bool { return m.UserID == userID })
+}
+
+// ThreadMessages возвращает ID последних (не больше limit) сообщений в топике чата, которые еще можно удалить
+func (i *MessageIndex) ThreadMessages(chatID int64, threadID int, limit int) []int {
+	return i.collect(chatID, limit, func(m indexedMessage) bool { return m.ThreadID == threadID })
+}
+
+func (i *MessageIndex) collect(chatID int64, limit int, match func(indexedMessage) bool) []int {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	var ids []int
+	messages := i.chats[chatID]
+	for idx := len(messages) - 1; idx >= 0 && len(ids) < limit; idx-- {
+		if time.Since(messages[idx].SentAt) > deletableAge {
+			break
+		}
+		if match(messages[idx]) {
+			ids = append(ids, messages[idx].ID)
+		}
+	}
+	return ids
+}
+
+// Remove забывает удаленные сообщения
+func (i *MessageIndex) Remove(chatID int64, ids []int) {
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	i.chats[chatID] = slices.DeleteFunc(i.chats[chatID], func(m indexedMessage) bool {
+		return slices.Contains(ids, m.ID)
+	})
+}
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/messages"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// actorID возвращает ID того, кто выполняет действие: канала или пользователя
+func actorID(chatMsg *ChatMessage) int64 {
+	if chatMsg.IsFromChannel() && chatMsg.Channel() != nil {
+		return chatMsg.Channel().ID
+	}
+	if chatMsg.Sender() != nil {
+		return chatMsg.Sender().ID
+	}
+	return 0
+}
+
+// actionLimit возвращает действия, которые считаются вместе с action, лимит и минимальную длительность.
+// Последнее значение false, если действие не ограничивается
+func actionLimit(chatMessageHandler *ChatMessageHandler, action string, durationMinutes uint) ([]string, int, uint, bool) {
+	limits := chatMessageHandler.Limits
+	switch action {
+	case admins.ActionBan, admins.ActionBlocklist:
+		return []string{admins.ActionBan, admins.ActionBlocklist}, limits.MaxBans, 0, limits.MaxBans > 0
+	case admins.ActionKick:
+		return []string{admins.ActionKick}, limits.MaxKicks, 0, limits.MaxKicks > 0
+	case admins.ActionMute, admins.ActionRestrict:
+		// Бессрочный рестрикт (0 минут) всегда считается долгим
+		if durationMinutes > 0 && durationMinutes < limits.LongMuteMinutes {
+			return nil, 0, 0, false
+		}
+		return []string{admins.ActionMute, admins.ActionRestrict}, limits.MaxLongMutes, limits.LongMuteMinutes, limits.MaxLongMutes > 0
+	}
+	return nil, 0, 0, false
+}
+
+// checkActionLimit проверяет лимит на разрушительные действия. Если админ превысил лимит,
+// его права приостанавливаются, главному админу уходит панель с кнопками, а действие не выполняется
+func checkActionLimit(c tele.Context, chatMessageHandler *ChatMessageHandler, action string, durationMinutes uint) bool {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return true
+	}
+	// Анонимные админы считаются по ID чата, от имени которого пишут: узнать, кто из админов за ними стоит, нельзя
+	adminID := actorID(chatMsg)
+	if adminID == chatMessageHandler.MainAdminID {
+		return true
+	}
+	actions, limit, minDuration, limited := actionLimit(chatMessageHandler, action, durationMinutes)
+	if !limited {
+		return true
+	}
+
+	since := time.Now().Add(-chatMessageHandler.Limits.Window)
+	count, err := chatMessageHandler.Rep.CountAdminActions(adminID, actions, minDuration, since)
+	if err != nil {
+		log.Printf("failed to check action limit of admin %d: %v", adminID, err)
+		return true
+	}
+	if count < int64(limit) {
+		return true
+	}
+
+	reason := fmt.Sprintf("превышен лимит %q: %d за %d мин", action, limit, int(chatMessageHandler.Limits.Window.Minutes()))
+	// Снимаем админку и в Telegram, иначе админ продолжит банить через интерфейс Telegram.
+	// Анонимного админа разжаловать нельзя, у него блокируются только действия от имени группы
+	var demotedRights []byte
+	if chatMsg.ChatAdmin() {
+		reason += ", действия от имени группы заблокированы"
+	} else {
+		demoted := admins.DemoteInChats(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, adminID)
+		var err error
+		demotedRights, err = json.Marshal(demoted)
+		if err != nil {
+			log.Printf("failed to marshal demoted rights of admin %d: %v", adminID, err)
+		}
+		reason += ", админка в Telegram снята"
+	}
+	if err := chatMessageHandler.Rep.SuspendAdmin(adminID, reason, string(demotedRights)); err != nil {
+		log.Printf("failed to suspend admin %d: %v", adminID, err)
+	}
+	alertMainAdmin(chatMessageHandler, adminID, chatMsg.Appeal(), reason)
+	messages.ReplyMessage(c, "Слишком много наказаний за короткое время. Твои админские права приостановлены до решения главного админа", chatMsg.ThreadID())
+	return false
+}
+
+// recordAction записывает выполненное действие модерации в журнал
+func recordAction(chatMessageHandler *ChatMessageHandler, action string, targetID int64, chatID int64, durationMinutes uint) {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return
+	}
+	entry := &database.ModerationAction{
+		AdminID:         actorID(chatMsg),
+		TargetID:        targetID,
+		ChatID:          chatID,
+		Action:          action,
+		DurationMinutes: durationMinutes,
+	}
+	// Прикладываем к записи сообщение, за которое наказали
+	if replyTo := chatMsg.ReplyTo(); chatMsg.IsReply() && replyTo != nil {
+		entry.EvidenceMsgID = replyTo.ID
+		entry.EvidenceText = replyTo.Text
+		if entry.EvidenceText == "" {
+			entry.EvidenceText = replyTo.Caption
+		}
+	}
+	if err := chatMessageHandler.Rep.SaveModerationAction(entry); err != nil {
+		log.Printf("failed to record moderation action: %v", err)
+	}
+}
+
+// alertMainAdmin отправляет главному админу панель восстановления прав и отката действий
+func alertMainAdmin(chatMessageHandler *ChatMessageHandler, adminID int64, appeal string, reason string) {
+	if chatMessageHandler.MainAdminID == 0 {
+		log.Printf("MAIN_ADMIN_ID is not set, can't alert about suspended admin %d", adminID)
+		return
+	}
+	since := time.Now().Add(-chatMessageHandler.Limits.RevertWindow)
+	recent, err := chatMessageHandler.Rep.GetRecentAdminActions(adminID, since)
+	if err != nil {
+		log.Printf("failed to get recent actions of admin %d: %v", adminID, err)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Права админа %s (%d) приостановлены: %s\n", appeal, adminID, reason))
+	sb.WriteString(fmt.Sprintf("\nДействия за последние %d ч:\n", int(chatMessageHandler.Limits.RevertWindow.Hours())))
+	for _, action := range recent {
+		sb.WriteString(fmt.Sprintf("%s %s %d", action.CreatedAt.In(database.MoscowTZ).Format("02.01 15:04"), action.Action, action.TargetID))
+		if action.DurationMinutes > 0 {
+			sb.WriteString(fmt.Sprintf(" (%d мин)", action.DurationMinutes))
+		}
+		if action.EvidenceText != "" {
+			evidence := action.EvidenceText
+			if utf8.RuneCountInString(evidence) > 100 {
+				evidence = string([]rune(evidence)[:100]) + "…"
+			}
+			sb.WriteString(fmt.Sprintf(": «%s»", evidence))
+		}
+		sb.WriteString("\n")
+	}
+
+	id := strconv.FormatInt(adminID, 10)
+	menu := &tele.ReplyMarkup{}
+	btnRestore := menu.Data("Вернуть права", "restore_admin_"+id)
+	btnRevert := menu.Data("Откатить все действия", "revert_actions_"+id)
+	menu.Inline(menu.Row(btnRestore), menu.Row(btnRevert))
+
+	_, err = chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, sb.String(), &tele.SendOptions{ReplyMarkup: menu})
+	if err != nil {
+		log.Printf("failed to alert main admin about suspended admin %d: %v", adminID, err)
+	}
+}
+
+// handleSafetyCallback обрабатывает кнопки панели приостановленного админа (только главный админ)
+func handleSafetyCallback(c tele.Context, chatMessageHandler *ChatMessageHandler, callbackData string) error {
+	callback := c.Callback()
+	if callback.Sender.ID != chatMessageHandler.MainAdminID {
+		return c.Respond(&tele.CallbackResponse{Text: "Эта кнопка не для тебя!"})
+	}
+
+	if strings.HasPrefix(callbackData, "restore_admin_") {
+		adminID, err := strconv.ParseInt(strings.TrimPrefix(callbackData, "restore_admin_"), 10, 64)
+		if err != nil {
+			return c.Respond()
+		}
+		suspension, err := chatMessageHandler.Rep.GetAdminSuspension(adminID)
+		if err != nil {
+			log.Printf("Failed to get suspension of admin %d: %v", adminID, err)
+			return c.Respond(&tele.CallbackResponse{Text: "Внутренняя ошибка базы данных", ShowAlert: true})
+		}
+		// Возвращаем админку в Telegram, снятую при приостановке
+		if suspension != nil && suspension.DemotedRights != "" {
+			var demoted []admins.DemotedRights
+			if err := json.Unmarshal([]byte(suspension.DemotedRights), &demoted); err != nil {
+				log.Printf("Failed to unmarshal demoted rights of admin %d: %v", adminID, err)
+			}
+			admins.PromoteInChats(chatMessageHandler.Bot, adminID, demoted)
+		}
+		if err := chatMessageHandler.Rep.LiftAdminSuspension(adminID); err != nil {
+			log.Printf("Failed to restore admin %d: %v", adminID, err)
+			return c.Respond(&tele.CallbackResponse{Text: "Внутренняя ошибка базы данных", ShowAlert: true})
+		}
+		c.Respond(&tele.CallbackResponse{Text: "Права восстановлены"})
+		chatMessageHandler.Bot.Send(&tele.User{ID: adminID}, "Главный админ вернул тебе админские права")
+		return c.Send(fmt.Sprintf("Права админа %d восстановлены", adminID))
+	}
+
+	adminID, err := strconv.ParseInt(strings.TrimPrefix(callbackData, "revert_actions_"), 10, 64)
+	if err != nil {
+		return c.Respond()
+	}
+	since := time.Now().Add(-chatMessageHandler.Limits.RevertWindow)
+	recent, err := chatMessageHandler.Rep.GetRecentAdminActions(adminID, since)
+	if err != nil {
+		log.Printf("Failed to get recent actions of admin %d: %v", adminID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Внутренняя ошибка базы данных", ShowAlert: true})
+	}
+	c.Respond(&tele.CallbackResponse{Text: "Откатываю..."})
+	reverted := 0
+	for _, action := range recent {
+		if err := admins.RevertModerationAction(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, action, chatMessageHandler.Rep); err != nil {
+			log.Printf("Failed to revert action %d: %v", action.ID, err)
+			continue
+		}
+		reverted++
+		time.Sleep(50 * time.Millisecond)
+	}
+	return c.Send(fmt.Sprintf("Откачено действий админа %d: %d из %d", adminID, reverted, len(recent)))
+}
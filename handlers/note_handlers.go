@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/database"
+	"strings"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Обработка команды "заметка <текст>" ответом на сообщение в чате.
+// Команда удаляется сразу, подтверждение приходит админу в личку
+func handleNoteReply(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if !canSeeDossier(chatMessageHandler) {
+		return nil
+	}
+	chatMessageHandler.Bot.Delete(c.Message())
+
+	text := noteText(chatMsg.Text(), 0)
+	if !chatMsg.IsReply() || chatMsg.ReplyToIsChannel() || text == "" {
+		chatMessageHandler.Bot.Send(chatMsg.Sender(), "Заметку нужно оставлять ответом на сообщение пользователя: \"заметка [текст]\"")
+		return nil
+	}
+
+	note := &database.UserNote{
+		UserID:   chatMsg.ReplyToID(),
+		AuthorID: chatMsg.Sender().ID,
+		Text:     text,
+	}
+	if err := chatMessageHandler.Rep.AddUserNote(note); err != nil {
+		log.Printf("Failed to add note: %v", err)
+		chatMessageHandler.Bot.Send(chatMsg.Sender(), "Не удалось сохранить заметку. Попробуй еще раз")
+		return nil
+	}
+	chatMessageHandler.Bot.Send(chatMsg.Sender(), fmt.Sprintf("Заметка о %s (%d) сохранена", chatMsg.ReplyToAppeal(), note.UserID))
+	return nil
+}
+
+// Обработка команды "заметка <id или @username> <текст>" (или /note) в личных сообщениях
+func handleNoteCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if !canSeeDossier(chatMessageHandler) {
+		return c.Send("Заметки доступны только админам.")
+	}
+
+	usage := "Не распознал команду. Вводи четко в формате \"заметка [id или @username] [текст]\""
+	parts := strings.Fields(chatMsg.Text())
+	if len(parts) < 3 {
+		return c.Send(usage)
+	}
+	text := noteText(chatMsg.Text(), 1)
+	if text == "" {
+		return c.Send(usage)
+	}
+	userID, err := resolveUserQuery(chatMessageHandler, parts[1])
+	if err != nil {
+		log.Printf("Failed to resolve user %s: %v", parts[1], err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if userID == 0 {
+		return c.Send(fmt.Sprintf("Пользователь %s не найден в базе", parts[1]))
+	}
+
+	note := &database.UserNote{
+		UserID:   userID,
+		AuthorID: chatMsg.Sender().ID,
+		Text:     text,
+	}
+	if err := chatMessageHandler.Rep.AddUserNote(note); err != nil {
+		log.Printf("Failed to add note: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	return c.Send(fmt.Sprintf("Заметка о пользователе %d сохранена", userID))
+}
+
+// noteText возвращает текст заметки из команды, пропуская слово команды и skip аргументов после него
+func noteText(command string, skip int) string {
+	rest := strings.TrimSpace(command)
+	for i := 0; i <= skip; i++ {
+		idx := strings.IndexFunc(rest, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' })
+		if idx == -1 {
+			return ""
+		}
+		rest = strings.TrimSpace(rest[idx:])
+	}
+	return rest
+}
+
+// formatNotes форматирует заметки для админов, prefix добавляется в начало каждой строки
+func formatNotes(chatMessageHandler *ChatMessageHandler, notes []database.UserNote, prefix string) string {
+	authors := make(map[int64]string)
+	var sb strings.Builder
+	for _, note := range notes {
+		author, ok := authors[note.AuthorID]
+		if !ok {
+			author = fmt.Sprintf("%d", note.AuthorID)
+			if user, err := chatMessageHandler.Rep.FindUser(note.AuthorID); err == nil && user != nil {
+				if user.Username != "" {
+					author = "@" + user.Username
+				} else if user.FirstName != "" {
+					author = user.FirstName
+				}
+			}
+			authors[note.AuthorID] = author
+		}
+		text := note.Text
+		if utf8.RuneCountInString(text) > 300 {
+			text = string([]rune(text)[:300]) + "…"
+		}
+		sb.WriteString(fmt.Sprintf("%s%s %s: %s\n", prefix, note.CreatedAt.In(database.MoscowTZ).Format("02.01.2006"), author, text))
+	}
+	return sb.String()
+}
@@ -0,0 +1,136 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/messages"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+const (
+	purgeDefaultCount = 100 // Сколько сообщений пользователя удалять по умолчанию
+	purgeMaxCount     = 500 // Максимум сообщений за одну зачистку
+	purgeBatchSize    = 100 // Максимум сообщений в одном вызове deleteMessages
+)
+
+// isPurgeCommand проверяет, является ли текст командой зачистки
+func isPurgeCommand(text string) bool {
+	parts := strings.Fields(text)
+	return len(parts) > 0 && (parts[0] == "зачистка" || parts[0] == "/purge")
+}
+
+// Обработка команды зачистки: "зачистка [N] [бан]" или "/purge [N] [бан]".
+// Ответом на сообщение удаляет последние N сообщений автора (и банит его, если указано "бан"),
+// без ответа - последние N сообщений в топике
+func handlePurge(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+
+	usage := "Не понял команду. Ответом на сообщение: \"зачистка [количество] [бан]\", без ответа: \"зачистка [количество]\" удалит последние сообщения в топике"
+	count := 0
+	ban := false
+	for _, part := range strings.Fields(strings.ToLower(chatMsg.Text()))[1:] {
+		if part == "бан" || part == "ban" {
+			ban = true
+			continue
+		}
+		n, err := strconv.Atoi(part)
+		if err != nil || n <= 0 {
+			return messages.ReplyMessage(c, usage, chatMsg.ThreadID())
+		}
+		count = min(n, purgeMaxCount)
+	}
+
+	chatID := c.Chat().ID
+	var ids []int
+	if chatMsg.IsReply() {
+		if chatMsg.ReplyToAdmin() {
+			return messages.ReplyMessage(c, "Ты не можешь зачищать других админов, соси писос", chatMsg.ThreadID())
+		}
+		if ban && !chatMsg.HasPermission(database.PermBan) {
+			return handleNotEnoughRights(c, chatMessageHandler)
+		}
+		if ban && !checkActionLimit(c, chatMessageHandler, admins.ActionBan, 0) {
+			return nil
+		}
+		if count == 0 {
+			count = purgeDefaultCount
+		}
+		ids = chatMessageHandler.Messages.UserMessages(chatID, chatMsg.ReplyToID(), count)
+		if replyTo := chatMsg.ReplyTo(); replyTo != nil && !slices.Contains(ids, replyTo.ID) {
+			ids = append(ids, replyTo.ID)
+		}
+	} else {
+		if ban || count == 0 {
+			return messages.ReplyMessage(c, usage, chatMsg.ThreadID())
+		}
+		ids = chatMessageHandler.Messages.ThreadMessages(chatID, chatMsg.ThreadID(), count)
+	}
+	ids = append(ids, c.Message().ID)
+
+	deleted := deleteMessagesBatched(chatMessageHandler.Bot, chatID, ids)
+	chatMessageHandler.Messages.Remove(chatID, ids)
+	// Команду тоже удалили, в отчете ее не считаем
+	deleted = max(deleted-1, 0)
+
+	result := fmt.Sprintf("Зачистка: удалено сообщений %d", deleted)
+	if chatMsg.IsReply() && ban {
+		purgeBan(c, chatMessageHandler)
+		result = fmt.Sprintf("%s идет нахуй из чатика. Удалено сообщений: %d", chatMsg.ReplyToAppeal(), deleted)
+	}
+	_, err := chatMessageHandler.Bot.Send(c.Chat(), result, &tele.SendOptions{ThreadID: chatMsg.ThreadID()})
+	return err
+}
+
+// purgeBan банит автора сообщения, на которое ответили командой зачистки
+func purgeBan(c tele.Context, chatMessageHandler *ChatMessageHandler) {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg.ReplyToIsChannel() {
+		channelID := chatMsg.ReplyToChannel().ID
+		channelData, err := chatMessageHandler.Rep.GetChannel(channelID)
+		if err != nil {
+			log.Printf("Failed to get channel data for channel %d: %v", channelID, err)
+			return
+		}
+		channelData.Status = "banned"
+		if err := chatMessageHandler.Rep.SaveChannel(&channelData); err != nil {
+			log.Printf("Failed to ban channel %d: %v", channelID, err)
+		}
+		return
+	}
+	user := chatMsg.ReplyTo().Sender
+	chatMember := &tele.ChatMember{User: user, Role: tele.Member}
+	admins.BanUser(chatMessageHandler.Bot, c.Chat(), chatMember, chatMessageHandler.Rep)
+	recordAction(chatMessageHandler, admins.ActionBan, user.ID, c.Chat().ID, 0)
+}
+
+// deleteMessagesBatched удаляет сообщения пачками через deleteMessages с паузой между вызовами.
+// Возвращает количество сообщений в успешно обработанных пачках
+func deleteMessagesBatched(bot *tele.Bot, chatID int64, ids []int) int {
+	deleted := 0
+	for start := 0; start < len(ids); start += purgeBatchSize {
+		end := min(start+purgeBatchSize, len(ids))
+		batch := make([]tele.Editable, 0, end-start)
+		for _, id := range ids[start:end] {
+			batch = append(batch, &tele.StoredMessage{MessageID: strconv.Itoa(id), ChatID: chatID})
+		}
+		if err := bot.DeleteMany(batch); err != nil {
+			log.Printf("Failed to delete messages batch in chat %d: %v", chatID, err)
+		} else {
+			deleted += len(batch)
+		}
+		if end < len(ids) {
+			time.Sleep(time.Second)
+		}
+	}
+	return deleted
+}
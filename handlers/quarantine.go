@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/messages"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// quarantineEnabled сообщает, включен ли карантин новичков
+func quarantineEnabled(chatMessageHandler *ChatMessageHandler) bool {
+	return chatMessageHandler.Quarantine.Hours > 0 || chatMessageHandler.Quarantine.Messages > 0
+}
+
+// quarantineTerms описывает условия карантина для приветствия новичка
+func quarantineTerms(chatMessageHandler *ChatMessageHandler) string {
+	settings := chatMessageHandler.Quarantine
+	switch {
+	case settings.Hours > 0 && settings.Messages > 0:
+		return fmt.Sprintf("первые %d ч. или %d сообщений", settings.Hours, settings.Messages)
+	case settings.Hours > 0:
+		return fmt.Sprintf("первые %d ч.", settings.Hours)
+	}
+	return fmt.Sprintf("первые %d сообщений", settings.Messages)
+}
+
+// checkQuarantine удаляет пересылки и ссылки новичка на карантине и считает его сообщения.
+// Возвращает true, если сообщение удалено
+func checkQuarantine(chatMessageHandler *ChatMessageHandler, c tele.Context, userData *database.User) bool {
+	if !userData.Quarantined || userData.Status != "active" {
+		return false
+	}
+	msg := c.Message()
+	if msg.IsForwarded() || msg.Origin != nil || admins.HasLinks(msg) {
+		log.Printf("Deleted forward or link from quarantined user %d", userData.UserID)
+		chatMessageHandler.Bot.Delete(msg)
+		return true
+	}
+
+	userData.QuarantineMessages++
+	limit := chatMessageHandler.Quarantine.Messages
+	if limit > 0 && userData.QuarantineMessages >= limit {
+		if err := admins.ReleaseQuarantine(chatMessageHandler.Bot, msg.Chat, userData, chatMessageHandler.Rep); err != nil {
+			log.Printf("Failed to release user %d from quarantine: %v", userData.UserID, err)
+			return false
+		}
+		log.Printf("User %d released from quarantine after %d messages", userData.UserID, limit)
+		return false
+	}
+	if err := chatMessageHandler.Rep.SaveUser(userData); err != nil {
+		log.Printf("Failed to save quarantine progress of user %d: %v", userData.UserID, err)
+	}
+	return false
+}
+
+// Обработка команды "выпустить" ответом на сообщение: досрочно снять карантин новичка
+func handleReleaseReply(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if !chatMsg.IsReply() || chatMsg.ReplyToIsChannel() {
+		return messages.ReplyMessage(c, "Кого выпустить с карантина? Ответь на сообщение новичка", chatMsg.ThreadID())
+	}
+	text, err := releaseFromQuarantine(chatMessageHandler, c.Chat(), chatMsg.ReplyToID())
+	if err != nil {
+		log.Printf("Failed to release user %d from quarantine: %v", chatMsg.ReplyToID(), err)
+		return messages.ReplyMessage(c, "Не получилось снять карантин. Попробуй еще раз", chatMsg.ThreadID())
+	}
+	return messages.ReplyMessage(c, fmt.Sprintf("%s: %s", chatMsg.ReplyToAppeal(), text), chatMsg.ThreadID())
+}
+
+// Обработка команды "выпустить <id или @username>" в личных сообщениях
+func handleReleaseCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	parts := strings.Fields(chatMessageHandler.ChatMessage.Text())
+	if len(parts) != 2 {
+		return c.Send("Не распознал команду. Вводи четко в формате \"выпустить [id или @username]\"")
+	}
+	userID, err := resolveUserQuery(chatMessageHandler, parts[1])
+	if err != nil {
+		log.Printf("Failed to resolve user %s: %v", parts[1], err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if userID == 0 {
+		return c.Send(fmt.Sprintf("Пользователь %s не найден в базе", parts[1]))
+	}
+	chat := &tele.Chat{ID: chatMessageHandler.QuizManager.QuizChatID}
+	text, err := releaseFromQuarantine(chatMessageHandler, chat, userID)
+	if err != nil {
+		log.Printf("Failed to release user %d from quarantine: %v", userID, err)
+		return c.Send("Не получилось снять карантин. Попробуй еще раз")
+	}
+	return c.Send(fmt.Sprintf("Пользователь %d: %s", userID, text))
+}
+
+// releaseFromQuarantine снимает карантин и возвращает текст ответа админу
+func releaseFromQuarantine(chatMessageHandler *ChatMessageHandler, chat *tele.Chat, userID int64) (string, error) {
+	userData, err := chatMessageHandler.Rep.GetUser(userID)
+	if err != nil {
+		return "", err
+	}
+	if !userData.Quarantined {
+		return "не на карантине", nil
+	}
+	if err := admins.ReleaseQuarantine(chatMessageHandler.Bot, chat, &userData, chatMessageHandler.Rep); err != nil {
+		return "", err
+	}
+	log.Printf("Admin %d released user %d from quarantine", chatMessageHandler.ChatMessage.Sender().ID, userID)
+	return "карантин снят, теперь можно медиа, ссылки и пересылки", nil
+}
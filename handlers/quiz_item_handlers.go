@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/activities"
+	"saxbot/database"
+	textcases "saxbot/text_cases"
+	"sort"
+	"strconv"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Максимальная длина сообщения со списком вопросов (лимит Telegram - 4096 символов)
+const quizListChunk = 3500
+
+// isQuizItemCommand проверяет, является ли текст командой управления вопросами квиза
+func isQuizItemCommand(text string) bool {
+	for _, command := range []string{"/quizadd", "/quizemoji", "/quizlist", "/quizfresh", "/quizshow", "/quizedit", "/quizoff", "/quizon"} {
+		if text == command || strings.HasPrefix(text, command+" ") || strings.HasPrefix(text, command+"\n") {
+			return true
+		}
+	}
+	return false
+}
+
+// Обработка команд управления вопросами квиза в личных сообщениях (только главный админ)
+func handleQuizItemCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	text := strings.TrimSpace(chatMessageHandler.ChatMessage.Text())
+	fields := strings.Fields(text)
+	command := strings.ToLower(fields[0])
+
+	switch command {
+	case "/quizadd":
+		return handleQuizAdd(c, chatMessageHandler, text, database.QuizItemQuote)
+	case "/quizemoji":
+		return handleQuizAdd(c, chatMessageHandler, text, database.QuizItemEmoji)
+	case "/quizlist":
+		kind := ""
+		if len(fields) > 1 {
+			switch strings.ToLower(fields[1]) {
+			case "цитаты":
+				kind = database.QuizItemQuote
+			case "кадры":
+				kind = database.QuizItemScreen
+			case "эмодзи":
+				kind = database.QuizItemEmoji
+			default:
+				return c.Send("Не распознал команду. Вводи четко в формате \"/quizlist [цитаты, кадры или эмодзи]\"")
+			}
+		}
+		return handleQuizList(c, chatMessageHandler, kind)
+	case "/quizfresh":
+		return handleQuizFresh(c, chatMessageHandler)
+	}
+
+	// Остальные команды работают с вопросом по ID
+	if len(fields) < 2 {
+		return c.Send(fmt.Sprintf("Не распознал команду. Вводи четко в формате \"%s [id]\"", command))
+	}
+	id, err := strconv.ParseUint(fields[1], 10, 64)
+	if err != nil {
+		return c.Send(fmt.Sprintf("Не распознал команду. Вводи четко в формате \"%s [id]\"", command))
+	}
+	item, err := chatMessageHandler.Rep.GetQuizItem(uint(id))
+	if err != nil {
+		log.Printf("Failed to get quiz item %d: %v", id, err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if item == nil {
+		return c.Send(fmt.Sprintf("Вопроса с ID %d нет", id))
+	}
+
+	switch command {
+	case "/quizshow":
+		return showQuizItem(c, item)
+	case "/quizoff", "/quizon":
+		item.Enabled = command == "/quizon"
+		if err := chatMessageHandler.Rep.SaveQuizItem(item); err != nil {
+			log.Printf("Failed to toggle quiz item %d: %v", item.ID, err)
+			return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+		}
+		if item.Enabled {
+			return c.Send(fmt.Sprintf("Вопрос %d снова участвует в квизе", item.ID))
+		}
+		return c.Send(fmt.Sprintf("Вопрос %d выключен и больше не попадет в квиз", item.ID))
+	}
+	return handleQuizEdit(c, chatMessageHandler, item, text)
+}
+
+// handleQuizAdd добавляет цитату или эмодзи-ребус: "/quizadd <песня>" или "/quizemoji <песня>" в первой строке,
+// цитата или эмодзи со следующей строки
+func handleQuizAdd(c tele.Context, chatMessageHandler *ChatMessageHandler, text string, kind string) error {
+	command, what, added := "/quizadd", "цитата", "Цитата из песни \"%s\" добавлена, ID %d"
+	if kind == database.QuizItemEmoji {
+		command, what, added = "/quizemoji", "эмодзи", "Эмодзи-ребус к песне \"%s\" добавлен, ID %d"
+	}
+	usage := fmt.Sprintf("Не распознал команду. Вводи четко: в первой строке \"%s [песня]\", со следующей строки %s. Кадр из клипа добавляется фотографией с названием песни в подписи", command, what)
+	firstLine, quote, _ := strings.Cut(text, "\n")
+	song := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(firstLine), command))
+	quote = strings.TrimSpace(quote)
+	if song == "" || quote == "" {
+		return c.Send(usage)
+	}
+	title, found := textcases.FindTrackTitle(song)
+	if !found {
+		return c.Send(fmt.Sprintf("Песни \"%s\" нет в треклистах. Название пиши как в каталоге", song))
+	}
+	item := &database.QuizItem{
+		Kind:       kind,
+		Quote:      quote,
+		SongName:   title,
+		Difficulty: 1,
+		Enabled:    true,
+		AddedBy:    chatMessageHandler.ChatMessage.Sender().ID,
+	}
+	if err := chatMessageHandler.Rep.AddQuizItem(item); err != nil {
+		log.Printf("Failed to add quiz quote: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	return c.Send(fmt.Sprintf(added, title, item.ID))
+}
+
+// handleQuizEdit меняет вопрос: "/quizedit <id> песня|сложность|цитата <значение>"
+func handleQuizEdit(c tele.Context, chatMessageHandler *ChatMessageHandler, item *database.QuizItem, text string) error {
+	usage := "Не распознал команду. Вводи четко в формате \"/quizedit [id] песня|сложность|цитата|эмодзи [значение]\""
+	parts := strings.SplitN(text, " ", 4)
+	if len(parts) < 4 {
+		return c.Send(usage)
+	}
+	value := strings.TrimSpace(parts[3])
+	switch strings.ToLower(parts[2]) {
+	case "песня":
+		title, found := textcases.FindTrackTitle(value)
+		if !found {
+			return c.Send(fmt.Sprintf("Песни \"%s\" нет в треклистах. Название пиши как в каталоге", value))
+		}
+		item.SongName = title
+	case "сложность":
+		difficulty, err := strconv.Atoi(value)
+		if err != nil || difficulty < 1 || difficulty > 3 {
+			return c.Send("Сложность - число от 1 до 3")
+		}
+		item.Difficulty = difficulty
+	case "цитата", "эмодзи":
+		if item.Kind == database.QuizItemScreen {
+			return c.Send("У кадра из клипа нет цитаты. Чтобы заменить кадр, выключи этот и пришли новый")
+		}
+		item.Quote = value
+	default:
+		return c.Send(usage)
+	}
+	if err := chatMessageHandler.Rep.SaveQuizItem(item); err != nil {
+		log.Printf("Failed to edit quiz item %d: %v", item.ID, err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	return c.Send(fmt.Sprintf("Вопрос %d обновлен", item.ID))
+}
+
+// handleQuizList присылает список вопросов квиза, разбивая его на несколько сообщений
+func handleQuizList(c tele.Context, chatMessageHandler *ChatMessageHandler, kind string) error {
+	items, err := chatMessageHandler.Rep.GetQuizItems(kind)
+	if err != nil {
+		log.Printf("Failed to get quiz items: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if len(items) == 0 {
+		return c.Send("Вопросов квиза пока нет")
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Вопросы квиза (%d):\n", len(items)))
+	for _, item := range items {
+		line := fmt.Sprintf("%d. %s, сложность %d", item.ID, item.SongName, item.Difficulty)
+		switch item.Kind {
+		case database.QuizItemScreen:
+			line += ", кадр"
+		case database.QuizItemEmoji:
+			line += ", эмодзи: " + item.Quote
+		default:
+			line += ": " + shortText(strings.ReplaceAll(item.Quote, "\n", " / "), 60)
+		}
+		if !item.Enabled {
+			line += " (выключен)"
+		}
+		if sb.Len()+len(line) > quizListChunk {
+			if err := c.Send(sb.String()); err != nil {
+				return err
+			}
+			sb.Reset()
+		}
+		sb.WriteString(line + "\n")
+	}
+	return c.Send(sb.String())
+}
+
+// handleQuizFresh показывает, сколько вопросов каждого вида можно загадать, не повторяя вопросы и песни
+func handleQuizFresh(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	rotation := chatMessageHandler.QuizManager.Rotation
+	freshness, err := activities.GetQuizFreshness(chatMessageHandler.Rep, rotation)
+	if err != nil {
+		log.Printf("Failed to get quiz freshness: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+
+	titles := map[string]string{
+		database.QuizItemQuote:  "Цитаты",
+		database.QuizItemScreen: "Кадры",
+		database.QuizItemEmoji:  "Эмодзи",
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Свежие вопросы квиза (вопрос не повторяется %d дн., песня - %d дн.):\n",
+		int(rotation.ItemWindow.Hours()/24), int(rotation.SongWindow.Hours()/24)))
+	for _, kind := range freshness {
+		sb.WriteString(fmt.Sprintf("\n%s: свежих %d из %d включенных, не было за %d дн. - %d\n", titles[kind.Kind], kind.Fresh, kind.Enabled,
+			int(max(rotation.ItemWindow, rotation.SongWindow).Hours()/24), kind.Unused))
+		albums := make([]string, 0, len(kind.Albums))
+		for album := range kind.Albums {
+			albums = append(albums, album)
+		}
+		sort.Strings(albums)
+		for _, album := range albums {
+			name := album
+			if name == "" {
+				name = "без альбома"
+			}
+			sb.WriteString(fmt.Sprintf("  %s: %d\n", name, kind.Albums[album]))
+		}
+	}
+	return c.Send(sb.String())
+}
+
+// showQuizItem присылает вопрос квиза так, как его увидят в чате
+func showQuizItem(c tele.Context, item *database.QuizItem) error {
+	status := "включен"
+	if !item.Enabled {
+		status = "выключен"
+	}
+	caption := fmt.Sprintf("Вопрос %d, %s\nПесня: %s\nСложность: %d", item.ID, status, item.SongName, item.Difficulty)
+	if item.Kind != database.QuizItemScreen {
+		return c.Send(fmt.Sprintf("%s\n\n%s", caption, item.Quote))
+	}
+	photo := &tele.Photo{File: tele.FromDisk(item.ScreenPath), Caption: caption}
+	if item.FileID != "" {
+		photo.File = tele.File{FileID: item.FileID}
+	}
+	if err := c.Send(photo); err != nil {
+		log.Printf("Failed to send quiz screen %d: %v", item.ID, err)
+		return c.Send(caption + "\n\nНе удалось отправить кадр")
+	}
+	return nil
+}
+
+// HandlePrivatePhoto добавляет кадр из клипа в вопросы квиза: главный админ присылает боту фотографию
+// с названием песни в подписи
+func HandlePrivatePhoto(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	msg := c.Message()
+	if msg.Sender == nil || msg.Sender.ID != chatMessageHandler.MainAdminID || msg.Photo == nil {
+		return nil
+	}
+	song := strings.TrimSpace(msg.Caption)
+	if song == "" {
+		return c.Send("Чтобы добавить кадр в квиз, пришли фотографию с названием песни в подписи")
+	}
+	title, found := textcases.FindTrackTitle(song)
+	if !found {
+		return c.Send(fmt.Sprintf("Песни \"%s\" нет в треклистах. Название пиши как в каталоге", song))
+	}
+	item := &database.QuizItem{
+		Kind:       database.QuizItemScreen,
+		FileID:     msg.Photo.FileID,
+		SongName:   title,
+		Difficulty: 1,
+		Enabled:    true,
+		AddedBy:    msg.Sender.ID,
+	}
+	if err := chatMessageHandler.Rep.AddQuizItem(item); err != nil {
+		log.Printf("Failed to add quiz screen: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	return c.Send(fmt.Sprintf("Кадр из клипа \"%s\" добавлен, ID %d", title, item.ID))
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"log"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Очки за правильный ответ в квизе-опросе по месту среди ответивших, остальным правильно ответившим - 1
+var quizPollPoints = []int{5, 3, 2}
+
+// HandlePollAnswer начисляет очки за правильный ответ в квизе-опросе. Чем раньше ответ, тем выше место
+func HandlePollAnswer(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	answer := c.PollAnswer()
+	if answer == nil || answer.Sender == nil {
+		return nil
+	}
+	quiz, err := chatMessageHandler.Rep.GetQuizByPollID(answer.PollID)
+	if err != nil {
+		log.Printf("Failed to get quiz by poll %s: %v", answer.PollID, err)
+		return nil
+	}
+	if quiz == nil || !quiz.IsActive {
+		return nil
+	}
+	if len(answer.Options) != 1 || answer.Options[0] != quiz.PollCorrect {
+		return nil
+	}
+	userID := answer.Sender.ID
+	// Админы в квизе не побеждают, как и в обычном квизе
+	if chatMessageHandler.Rep.IsAdmin(userID) {
+		return nil
+	}
+
+	// Ответы на опрос приходят параллельно, места раздаются в транзакции с блокировкой
+	rank, added, err := chatMessageHandler.Rep.AddQuizPollPoints(quiz.ID, userID, quizPollPoints)
+	if err != nil {
+		log.Printf("Failed to add quiz poll points: %v", err)
+		return nil
+	}
+	if added {
+		log.Printf("User %d answered quiz poll %d correctly, rank %d", userID, quiz.ID, rank)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/activities"
+	"saxbot/database"
+	"saxbot/messages"
+	"strings"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Сколько строк показывать в таблицах очков квиза
+const quizTopSize = 10
+
+// Обработка команды "топ квиза": таблица текущего сезона, таблица за все время и место спросившего
+func handleQuizTop(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	rep := chatMessageHandler.Rep
+	userID := chatMsg.Sender().ID
+	season := database.SeasonOf(time.Now())
+
+	var sb strings.Builder
+	for _, table := range []struct {
+		title  string
+		season string
+	}{
+		{fmt.Sprintf("Топ квиза, сезон %s:", activities.SeasonTitle(season)), season},
+		{"Топ квиза за все время:", ""},
+	} {
+		standings, err := rep.GetQuizStandings(table.season, quizTopSize)
+		if err != nil {
+			log.Printf("Failed to get quiz standings: %v", err)
+			return messages.ReplyMessage(c, "Внутренняя ошибка базы данных. Попробуй еще раз", chatMsg.ThreadID())
+		}
+		if sb.Len() > 0 {
+			sb.WriteString("\n")
+		}
+		sb.WriteString(table.title + "\n")
+		if len(standings) == 0 {
+			sb.WriteString("Пока никто не набрал очков\n")
+		}
+		for _, standing := range standings {
+			sb.WriteString(fmt.Sprintf("%d. %s - %d\n", standing.Rank, activities.QuizPlayerName(rep, standing.UserID), standing.Points))
+		}
+
+		rank, points, err := rep.GetQuizStanding(table.season, userID)
+		if err != nil {
+			log.Printf("Failed to get quiz standing of user %d: %v", userID, err)
+		} else if rank == 0 {
+			sb.WriteString("У тебя пока нет очков\n")
+		} else {
+			sb.WriteString(fmt.Sprintf("Твое место: %d, очков: %d\n", rank, points))
+		}
+	}
+	return messages.ReplyMessage(c, sb.String(), chatMsg.ThreadID())
+}
@@ -0,0 +1,28 @@
+package handlers
+
+import (
+	"log"
+	"saxbot/admins"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// ReconcileSanctions сверяет статусы пользователей в базе с их правами в чате квиза
+// и отправляет главному админу отчет обо всем, что было исправлено
+func ReconcileSanctions(chatMessageHandler *ChatMessageHandler) {
+	chat := &tele.Chat{ID: chatMessageHandler.QuizManager.QuizChatID}
+	result, err := admins.ReconcileSanctions(chatMessageHandler.Bot, chat, chatMessageHandler.Rep)
+	if err != nil {
+		log.Printf("failed to reconcile sanctions: %v", err)
+		return
+	}
+	log.Printf("Reconciled sanctions: checked %d, left %d, fixed %d, failed %d", result.Checked, result.Left, len(result.Fixed), len(result.Failed))
+
+	if !result.HasChanges() || chatMessageHandler.MainAdminID == 0 {
+		return
+	}
+	_, err = chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, result.Report())
+	if err != nil {
+		log.Printf("failed to send reconcile report to main admin: %v", err)
+	}
+}
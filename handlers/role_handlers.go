@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+var roleCommands = []string{"/roles", "/role", "/grant", "/revoke", "/promote", "/demote", "/temproles", "/temprole", "/untemprole"}
+
+// Kind временных ролей, выданных вручную командой /temprole
+const tempRoleManual = "manual"
+
+// isRoleCommand проверяет, является ли текст командой управления ролями
+func isRoleCommand(text string) bool {
+	parts := strings.Fields(text)
+	if len(parts) == 0 {
+		return false
+	}
+	for _, command := range roleCommands {
+		if parts[0] == command {
+			return true
+		}
+	}
+	return false
+}
+
+// Обработка команд управления ролями (только главный админ):
+// /roles, /role <имя> <ранг> <разрешения>, /grant <id> <роль>, /revoke <id>, /promote <id>, /demote <id>,
+// /temproles, /temprole <id> <срок> <роль или разрешения> [тег], /untemprole <id>
+func handleRoleCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	parts := strings.Fields(chatMsg.Text())
+	command := strings.ToLower(parts[0])
+
+	switch command {
+	case "/roles":
+		return handleShowRoles(c, chatMessageHandler)
+
+	case "/role":
+		if len(parts) != 4 {
+			return c.Send(fmt.Sprintf("Неправильный формат команды. Пример: \"/role moderator 2 warn,mute,restrict\"\nДоступные разрешения: %s", strings.Join(database.AllPermissions, ", ")))
+		}
+		rank, err := strconv.Atoi(parts[2])
+		if err != nil {
+			return c.Send("Ранг роли должен быть числом")
+		}
+		role := &database.Role{
+			Name:        strings.ToLower(parts[1]),
+			Rank:        rank,
+			Permissions: strings.ToLower(parts[3]),
+		}
+		if err := chatMessageHandler.Rep.SaveRole(role); err != nil {
+			log.Printf("Failed to save role %s: %v", role.Name, err)
+			return c.Send(fmt.Sprintf("Не удалось сохранить роль: %v", err))
+		}
+		return c.Send(fmt.Sprintf("Роль %s сохранена: ранг %d, разрешения %s", role.Name, role.Rank, strings.Join(role.PermissionList(), ", ")))
+
+	case "/grant":
+		if len(parts) != 3 {
+			return c.Send("Неправильный формат команды. Вводи четко в формате \"/grant [id] [роль]\"")
+		}
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return c.Send("Неправильный формат команды (id)")
+		}
+		roleName := strings.ToLower(parts[2])
+		role, err := chatMessageHandler.Rep.GetRole(roleName)
+		if err != nil {
+			return c.Send("Внутренняя ошибка базы данных")
+		}
+		if role == nil {
+			return c.Send(fmt.Sprintf("Роли %s не существует. Список ролей: /roles", roleName))
+		}
+		user, err := chatMessageHandler.Rep.GetUser(userID)
+		if err != nil {
+			return c.Send("Внутренняя ошибка базы данных")
+		}
+		if err := chatMessageHandler.Rep.SaveAdmin(user, role.Name); err != nil {
+			log.Printf("Failed to grant role %s to user %d: %v", role.Name, userID, err)
+			return c.Send("Внутренняя ошибка базы данных")
+		}
+		return c.Send(fmt.Sprintf("Пользователь %d теперь админ с ролью %s", userID, role.Name))
+
+	case "/revoke":
+		if len(parts) != 2 {
+			return c.Send("Неправильный формат команды. Вводи четко в формате \"/revoke [id]\"")
+		}
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return c.Send("Неправильный формат команды (id)")
+		}
+		if userID == chatMessageHandler.MainAdminID {
+			return c.Send("Главного админа разжаловать нельзя")
+		}
+		if err := chatMessageHandler.Rep.RemoveAdmin(userID); err != nil {
+			log.Printf("Failed to revoke admin %d: %v", userID, err)
+			return c.Send("Внутренняя ошибка базы данных")
+		}
+		admins.DemoteInChats(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, userID)
+		return c.Send(fmt.Sprintf("Пользователь %d больше не админ, права в чатах сняты", userID))
+
+	case "/promote", "/demote":
+		if len(parts) != 2 {
+			return c.Send(fmt.Sprintf("Неправильный формат команды. Вводи четко в формате \"%s [id]\"", command))
+		}
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return c.Send("Неправильный формат команды (id)")
+		}
+		delta := "+"
+		if command == "/demote" {
+			delta = "-"
+		}
+		newRole, err := chatMessageHandler.Rep.PromoteAdmin(userID, delta)
+		if err != nil {
+			log.Printf("Failed to change role of admin %d: %v", userID, err)
+			if command == "/demote" {
+				return c.Send("Не удалось понизить админа: возможно, у него уже самая младшая роль. Снять админку полностью можно командой /revoke")
+			}
+			return c.Send("Не удалось продвинуть админа: возможно, у него уже самая старшая роль")
+		}
+		if command == "/demote" {
+			return c.Send(fmt.Sprintf("Админ %d понижен до роли %s", userID, newRole))
+		}
+		return c.Send(fmt.Sprintf("Успешно продвинули админа %d до роли %s", userID, newRole))
+
+	case "/temproles":
+		return handleShowTempRoles(c, chatMessageHandler)
+
+	case "/temprole":
+		return handleGrantTempRole(c, chatMessageHandler, parts)
+
+	case "/untemprole":
+		if len(parts) != 2 {
+			return c.Send("Неправильный формат команды. Вводи четко в формате \"/untemprole [id]\"")
+		}
+		userID, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return c.Send("Неправильный формат команды (id)")
+		}
+		revoked, err := admins.RevokeUserTempRoles(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, userID, chatMessageHandler.Rep)
+		if err != nil {
+			log.Printf("Failed to revoke temp roles of user %d: %v", userID, err)
+			return c.Send("Внутренняя ошибка базы данных")
+		}
+		if revoked == 0 {
+			return c.Send(fmt.Sprintf("У пользователя %d нет временных ролей", userID))
+		}
+		return c.Send(fmt.Sprintf("Снято временных ролей у пользователя %d: %d", userID, revoked))
+	}
+
+	return nil
+}
+
+// handleGrantTempRole выдает временную роль: /temprole <id> <срок> <роль или разрешения> [тег]
+func handleGrantTempRole(c tele.Context, chatMessageHandler *ChatMessageHandler, parts []string) error {
+	usage := "Неправильный формат команды. Пример: \"/temprole 123456 7d warn,mute Стажер\" или \"/temprole 123456 1d junior\"\nСрок: 30m, 12h, 7d"
+	if len(parts) < 4 {
+		return c.Send(usage)
+	}
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Send("Неправильный формат команды (id)")
+	}
+	duration, err := parseTempRoleDuration(parts[2])
+	if err != nil {
+		return c.Send(usage)
+	}
+
+	// Третий аргумент - имя роли или список разрешений через запятую
+	permissions := strings.ToLower(parts[3])
+	role, err := chatMessageHandler.Rep.GetRole(permissions)
+	if err != nil {
+		return c.Send("Внутренняя ошибка базы данных")
+	}
+	if role != nil {
+		permissions = role.Permissions
+	}
+
+	tag := strings.Join(parts[4:], " ")
+	if utf8.RuneCountInString(tag) > 16 {
+		return c.Send("Тег не может быть длиннее 16 символов")
+	}
+
+	expiresAt := time.Now().Add(duration)
+	tempRole := &database.TempRole{
+		UserID:      userID,
+		Kind:        tempRoleManual,
+		Permissions: permissions,
+		Tag:         tag,
+		GrantedBy:   chatMessageHandler.ChatMessage.Sender().ID,
+		ExpiresAt:   &expiresAt,
+	}
+	if err := admins.GrantTempRole(chatMessageHandler.Bot, chatMessageHandler.AllowedChats, tempRole, chatMessageHandler.Rep); err != nil {
+		log.Printf("Failed to grant temp role to user %d: %v", userID, err)
+		return c.Send(fmt.Sprintf("Не удалось выдать временную роль: %v\nДоступные разрешения: %s", err, strings.Join(database.AllPermissions, ", ")))
+	}
+	return c.Send(fmt.Sprintf("Пользователь %d получил временную роль (%s) до %s",
+		userID, strings.Join(tempRole.PermissionList(), ", "), expiresAt.In(database.MoscowTZ).Format("02.01.2006 15:04")))
+}
+
+// parseTempRoleDuration разбирает срок временной роли: 30m, 12h, 7d (или 30м, 12ч, 7д)
+func parseTempRoleDuration(text string) (time.Duration, error) {
+	text = strings.ToLower(text)
+	units := map[string]time.Duration{
+		"m": time.Minute, "м": time.Minute,
+		"h": time.Hour, "ч": time.Hour,
+		"d": 24 * time.Hour, "д": 24 * time.Hour,
+	}
+	for suffix, unit := range units {
+		if !strings.HasSuffix(text, suffix) {
+			continue
+		}
+		value, err := strconv.Atoi(strings.TrimSuffix(text, suffix))
+		if err != nil || value <= 0 {
+			return 0, fmt.Errorf("invalid duration %q", text)
+		}
+		return time.Duration(value) * unit, nil
+	}
+	return 0, fmt.Errorf("invalid duration %q", text)
+}
+
+// handleShowTempRoles показывает действующие временные роли
+func handleShowTempRoles(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	roles, err := chatMessageHandler.Rep.GetAllTempRoles()
+	if err != nil {
+		return c.Send("Внутренняя ошибка базы данных")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Временные роли:\n")
+	if len(roles) == 0 {
+		sb.WriteString("нет\n")
+	}
+	for _, role := range roles {
+		until := "до отзыва"
+		if role.ExpiresAt != nil {
+			until = "до " + role.ExpiresAt.In(database.MoscowTZ).Format("02.01.2006 15:04")
+		}
+		sb.WriteString(fmt.Sprintf("id: %d, %s, %s, тег: %q, %s\n", role.UserID, role.Kind, strings.Join(role.PermissionList(), ", "), role.Tag, until))
+	}
+	sb.WriteString("\n/temprole [id] [срок] [роль или разрешения] [тег] - выдать временную роль\n/untemprole [id] - снять временные роли")
+	return c.Send(sb.String())
+}
+
+// handleShowRoles показывает роли с разрешениями и список админов
+func handleShowRoles(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	roles, err := chatMessageHandler.Rep.GetRoles()
+	if err != nil {
+		return c.Send("Внутренняя ошибка базы данных")
+	}
+	adminsList, err := chatMessageHandler.Rep.GetAdmins()
+	if err != nil {
+		return c.Send("Внутренняя ошибка базы данных")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Роли:\n")
+	for _, role := range roles {
+		sb.WriteString(fmt.Sprintf("%d. %s: %s\n", role.Rank, role.Name, strings.Join(role.PermissionList(), ", ")))
+	}
+	sb.WriteString("\nАдмины:\n")
+	for _, admin := range adminsList {
+		user, err := chatMessageHandler.Rep.GetUser(admin.ID)
+		if err != nil {
+			sb.WriteString(fmt.Sprintf("id: %d, роль: %s\n", admin.ID, admin.AdminRole))
+			continue
+		}
+		sb.WriteString(fmt.Sprintf("@%s, имя: %s, id: %d, роль: %s\n", user.Username, user.FirstName, admin.ID, admin.AdminRole))
+	}
+	sb.WriteString("\n/role [имя] [ранг] [разрешения] - создать или изменить роль\n/grant [id] [роль] - назначить админа\n/promote [id], /demote [id] - повысить или понизить по рангу\n/revoke [id] - снять админку, в том числе в Telegram")
+	return c.Send(sb.String())
+}
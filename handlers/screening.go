@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/spam"
+	"strconv"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Что делать с подозрительным профилем при входе
+const (
+	screenHold = "hold" // Замутить до решения админа
+	screenKick = "kick" // Кикнуть сразу
+	screenOff  = "off"  // Не проверять
+)
+
+// screenJoinedUser проверяет профиль вошедшего пользователя. Подозрительного пользователя кикает
+// или задерживает до решения админа и присылает главному админу карточку. Возвращает true,
+// если пользователь подозрительный и обычное приветствие показывать не нужно
+func screenJoinedUser(chatMessageHandler *ChatMessageHandler, chat *tele.Chat, joinedUser *tele.User, userData *database.User) bool {
+	if chatMessageHandler.Screener == nil || chatMessageHandler.ScreenAction == screenOff {
+		return false
+	}
+	if joinedUser.ID == chatMessageHandler.MainAdminID || chatMessageHandler.Rep.IsAdmin(joinedUser.ID) {
+		return false
+	}
+	profile := spam.Profile{FirstName: joinedUser.FirstName, LastName: joinedUser.LastName, Username: joinedUser.Username}
+	reasons := chatMessageHandler.Screener.Screen(profile, chatMessageHandler.GetAdminsUsernames())
+	if len(reasons) == 0 {
+		return false
+	}
+	log.Printf("User %d has suspicious profile (%s), action: %s", joinedUser.ID, strings.Join(reasons, "; "), chatMessageHandler.ScreenAction)
+
+	member := &tele.ChatMember{User: joinedUser, Role: tele.Member}
+	if chatMessageHandler.ScreenAction == screenKick {
+		if err := admins.KickUser(chatMessageHandler.Bot, chat, member); err != nil {
+			log.Printf("Failed to kick suspicious user %d: %v", joinedUser.ID, err)
+		}
+		sendScreeningCard(chatMessageHandler, chat, joinedUser, reasons, "Пользователь кикнут", nil)
+		return true
+	}
+
+	// Задерживаем: мутим до решения админа
+	userData.Status = "held"
+	if err := chatMessageHandler.Rep.SaveUser(userData); err != nil {
+		log.Printf("Failed to save held status for user %d: %v", joinedUser.ID, err)
+	}
+	member.Rights = tele.Rights{CanSendMessages: false}
+	if err := chatMessageHandler.Bot.Restrict(chat, member); err != nil {
+		log.Printf("Failed to restrict held user %d: %v", joinedUser.ID, err)
+	}
+
+	data := fmt.Sprintf("%d_%d", chat.ID, joinedUser.ID)
+	menu := &tele.ReplyMarkup{}
+	btnApprove := menu.Data("Впустить", "screen_ok_"+data)
+	btnKick := menu.Data("Кикнуть", "screen_kick_"+data)
+	menu.Inline(menu.Row(btnApprove, btnKick))
+	sendScreeningCard(chatMessageHandler, chat, joinedUser, reasons, "Пользователь замучен до твоего решения", menu)
+	return true
+}
+
+// sendScreeningCard присылает главному админу карточку подозрительного профиля
+func sendScreeningCard(chatMessageHandler *ChatMessageHandler, chat *tele.Chat, user *tele.User, reasons []string, status string, menu *tele.ReplyMarkup) {
+	if chatMessageHandler.MainAdminID == 0 {
+		log.Printf("MAIN_ADMIN_ID is not set, can't send screening card for user %d", user.ID)
+		return
+	}
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("<b>Подозрительный профиль при входе в чат %d</b>\n", chat.ID))
+	sb.WriteString(fmt.Sprintf("Имя: %s\n", html.EscapeString(strings.TrimSpace(user.FirstName+" "+user.LastName))))
+	if user.Username != "" {
+		sb.WriteString(fmt.Sprintf("Username: @%s\n", html.EscapeString(user.Username)))
+	}
+	sb.WriteString(fmt.Sprintf("Telegram ID: <code>%d</code>\n", user.ID))
+	sb.WriteString("Причины:\n")
+	for _, reason := range reasons {
+		sb.WriteString("- " + html.EscapeString(reason) + "\n")
+	}
+	sb.WriteString("\n" + status)
+
+	opts := &tele.SendOptions{ParseMode: tele.ModeHTML}
+	if menu != nil {
+		opts.ReplyMarkup = menu
+	}
+	if _, err := chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, sb.String(), opts); err != nil {
+		log.Printf("Failed to send screening card for user %d: %v", user.ID, err)
+	}
+}
+
+// handleScreeningCallback обрабатывает кнопки карточки: screen_ok_<chat>_<user>, screen_kick_<chat>_<user>
+func handleScreeningCallback(c tele.Context, chatMessageHandler *ChatMessageHandler, callbackData string) error {
+	adminID := c.Callback().Sender.ID
+	if adminID != chatMessageHandler.MainAdminID && !chatMessageHandler.Rep.AdminHasPermission(adminID, database.PermBan) {
+		return c.Respond(&tele.CallbackResponse{Text: "Эта кнопка не для тебя!"})
+	}
+
+	approve := strings.HasPrefix(callbackData, "screen_ok_")
+	data := strings.TrimPrefix(strings.TrimPrefix(callbackData, "screen_ok_"), "screen_kick_")
+	parts := strings.Split(data, "_")
+	if len(parts) != 2 {
+		return c.Respond()
+	}
+	chatID, err := strconv.ParseInt(parts[0], 10, 64)
+	if err != nil {
+		return c.Respond()
+	}
+	userID, err := strconv.ParseInt(parts[1], 10, 64)
+	if err != nil {
+		return c.Respond()
+	}
+
+	userData, err := chatMessageHandler.Rep.GetUser(userID)
+	if err != nil {
+		return c.Respond(&tele.CallbackResponse{Text: "Внутренняя ошибка базы данных", ShowAlert: true})
+	}
+	if userData.Status != "held" {
+		c.Respond(&tele.CallbackResponse{Text: "Решение по этому пользователю уже принято"})
+		return c.Edit(c.Message().Text + "\n\nРешение уже принято")
+	}
+
+	chat := &tele.Chat{ID: chatID}
+	user := &tele.User{ID: userID, FirstName: userData.FirstName, Username: userData.Username}
+	if approve {
+		welcomeNewUser(chatMessageHandler, chat, user, &userData, nil)
+		log.Printf("Admin %d approved held user %d", adminID, userID)
+		c.Respond(&tele.CallbackResponse{Text: "Пользователь впущен"})
+		return c.Edit(c.Message().Text + "\n\nВпущен, ждем нажатия \"Я не бот!\"")
+	}
+
+	if err := admins.KickUser(chatMessageHandler.Bot, chat, &tele.ChatMember{User: user, Role: tele.Member}); err != nil {
+		log.Printf("Failed to kick held user %d: %v", userID, err)
+		return c.Respond(&tele.CallbackResponse{Text: "Не удалось кикнуть пользователя", ShowAlert: true})
+	}
+	// После кика профиль проверяется заново при следующем входе
+	userData.Status = "active"
+	if err := chatMessageHandler.Rep.SaveUser(&userData); err != nil {
+		log.Printf("Failed to reset status of kicked user %d: %v", userID, err)
+	}
+	log.Printf("Admin %d kicked held user %d", adminID, userID)
+	c.Respond(&tele.CallbackResponse{Text: "Пользователь кикнут"})
+	return c.Edit(c.Message().Text + "\n\nКикнут")
+}
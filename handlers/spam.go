@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/spam"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// spamInput собирает данные сообщения и автора для антиспама
+func spamInput(chatMessageHandler *ChatMessageHandler, msg *tele.Message, user *database.User) spam.Input {
+	in := spam.Input{
+		Text:        msg.Text,
+		SenderNames: []string{msg.Sender.FirstName, msg.Sender.LastName, msg.Sender.Username},
+		IsNewcomer: user.Status == "new_user" || user.MessageCount < chatMessageHandler.SpamSettings.NewcomerMessages ||
+			(user.VerifiedAt != nil && time.Since(*user.VerifiedAt) < 24*time.Hour),
+		ForwardedFromChannel: (msg.OriginalChat != nil && msg.OriginalChat.Type == tele.ChatChannel) ||
+			(msg.Origin != nil && msg.Origin.Type == "channel"),
+	}
+	if in.Text == "" {
+		in.Text = msg.Caption
+	}
+	for _, entities := range []tele.Entities{msg.Entities, msg.CaptionEntities} {
+		for _, entity := range entities {
+			switch entity.Type {
+			case tele.EntityURL, tele.EntityTextLink:
+				in.URLs++
+			case tele.EntityMention, tele.EntityTMention:
+				in.Mentions++
+			}
+		}
+	}
+	return in
+}
+
+// checkSpam оценивает сообщение антиспамом, записывает решение и применяет действие.
+// Возвращает true, если сообщение удалено и дальше обрабатывать его не нужно
+func checkSpam(chatMessageHandler *ChatMessageHandler, msg *tele.Message, user *database.User) bool {
+	engine := chatMessageHandler.Spam
+	if engine == nil || msg == nil || msg.Sender == nil || msg.SenderChat != nil || user == nil {
+		return false
+	}
+	if msg.Sender.ID == chatMessageHandler.MainAdminID || chatMessageHandler.Rep.IsAdmin(msg.Sender.ID) {
+		return false
+	}
+
+	decision := engine.Evaluate(spamInput(chatMessageHandler, msg, user))
+	if decision.Score == 0 {
+		return false
+	}
+	log.Printf("Spam check for message %d from user %d: score %d, action %s, signals: %s, dry run: %v",
+		msg.ID, msg.Sender.ID, decision.Score, decision.Action, decision.Summary(), decision.DryRun)
+	// В базу попадают только решения, по которым что-то сделано (или было бы сделано в тестовом режиме):
+	// пропущенные сообщения новичков не копятся вместе с текстом
+	if decision.Action == spam.ActionAllow {
+		return false
+	}
+
+	text := msg.Text
+	if text == "" {
+		text = msg.Caption
+	}
+	record := &database.SpamDecision{
+		ChatID:    msg.Chat.ID,
+		MessageID: msg.ID,
+		UserID:    msg.Sender.ID,
+		Score:     decision.Score,
+		Action:    decision.Action,
+		Signals:   decision.Summary(),
+		DryRun:    decision.DryRun,
+		Text:      text,
+	}
+	if err := chatMessageHandler.Rep.SaveSpamDecision(record); err != nil {
+		log.Printf("Failed to save spam decision: %v", err)
+	}
+
+	if decision.DryRun {
+		reportSpam(chatMessageHandler, msg, record)
+		return false
+	}
+
+	chatMessageHandler.Bot.Delete(msg)
+	member := &tele.ChatMember{User: msg.Sender, Role: tele.Member}
+	switch decision.Action {
+	case spam.ActionMute:
+		admins.MuteUser(chatMessageHandler.Bot, msg.Chat, member, chatMessageHandler.Rep, chatMessageHandler.SpamSettings.MuteMinutes)
+		reportSpam(chatMessageHandler, msg, record)
+	case spam.ActionBan:
+		admins.BanUser(chatMessageHandler.Bot, msg.Chat, member, chatMessageHandler.Rep)
+		reportSpam(chatMessageHandler, msg, record)
+	}
+	return true
+}
+
+// reportSpam сообщает главному админу о решении антиспама
+func reportSpam(chatMessageHandler *ChatMessageHandler, msg *tele.Message, record *database.SpamDecision) {
+	if chatMessageHandler.MainAdminID == 0 {
+		return
+	}
+	prefix := "Антиспам"
+	if record.DryRun {
+		prefix = "Антиспам (тестовый режим, ничего не сделано)"
+	}
+	appeal := msg.Sender.FirstName
+	if msg.Sender.Username != "" {
+		appeal = "@" + msg.Sender.Username
+	}
+	report := fmt.Sprintf("%s: %s, %s (%d)\nБаллы: %d (%s)\nТекст: %s",
+		prefix, record.Action, appeal, record.UserID, record.Score, record.Signals, shortText(record.Text, 300))
+	if link := messageLink(record.ChatID, record.MessageID); link != "" && record.DryRun {
+		report += "\n" + link
+	}
+	_, err := chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, report, &tele.SendOptions{DisableWebPagePreview: true})
+	if err != nil {
+		log.Printf("Failed to send spam report to main admin: %v", err)
+	}
+}
+
+// handleSpamLog показывает последние решения антиспама: /spamlog [количество] (только главный админ)
+func handleSpamLog(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	parts := strings.Fields(chatMessageHandler.ChatMessage.Text())
+	limit := 20
+	if len(parts) > 1 {
+		value, err := strconv.Atoi(parts[1])
+		if err != nil || value <= 0 {
+			return c.Send("Не распознал команду. Вводи четко в формате \"/spamlog [количество]\"")
+		}
+		limit = min(value, 50)
+	}
+	decisions, err := chatMessageHandler.Rep.GetRecentSpamDecisions(limit)
+	if err != nil {
+		log.Printf("Failed to get spam decisions: %v", err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if len(decisions) == 0 {
+		return c.Send("Антиспам пока ничего не отметил")
+	}
+
+	var sb strings.Builder
+	sb.WriteString("Последние решения антиспама:\n")
+	for _, d := range decisions {
+		dryRun := ""
+		if d.DryRun {
+			dryRun = " (тест)"
+		}
+		sb.WriteString(fmt.Sprintf("\n%s id %d: %d баллов, %s%s\n%s\n«%s»\n",
+			d.CreatedAt.In(database.MoscowTZ).Format("02.01 15:04"), d.UserID, d.Score, d.Action, dryRun, d.Signals, shortText(d.Text, 100)))
+	}
+	return c.Send(sb.String())
+}
+
+// shortText обрезает текст до limit символов
+func shortText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+	return string([]rune(text)[:limit]) + "…"
+}
@@ -5,7 +5,10 @@ import (
 	"log"
 	"saxbot/activities"
 	"saxbot/database"
+	"saxbot/environment"
+	"saxbot/spam"
 	"slices"
+	"sync"
 
 	tele "gopkg.in/telebot.v4"
 )
@@ -18,8 +21,22 @@ type ChatMessageHandler struct {
 	Rep             *database.PostgresRepository
 	Bot             *tele.Bot
 	ChatMessage     *ChatMessage
+	MainAdminID     int64
 	KatyaID         int64
-	UserStates      map[int64]string // Состояния пользователей (userID -> state)
+	Limits          environment.LimitsEnvironment     // Лимиты на разрушительные действия админов
+	UserStates      map[int64]string                  // Состояния пользователей (userID -> state)
+	Messages        *MessageIndex                     // Последние сообщения чатов для зачистки
+	Archive         environment.ArchiveEnvironment    // Архив сообщений чатов
+	Votes           *VoteManager                      // Голосования участников за мут и бан
+	Spam            *spam.Engine                      // Антиспам
+	SpamSettings    environment.SpamEnvironment       // Настройки антиспама
+	Screener        *spam.Screener                    // Проверка профилей при входе
+	ScreenAction    string                            // Что делать с подозрительным профилем: hold, kick или off
+	JoinSettings    environment.JoinEnvironment       // Заявки на вступление в чат
+	Quarantine      environment.QuarantineEnvironment // Карантин новичков
+
+	adminsMu            sync.RWMutex // Защищает AdminsUsernames, которые обновляет сверка админов
+	lastAdminSyncReport string       // Последний отправленный отчет сверки, чтобы не повторяться
 }
 
 type ChatMessage struct {
@@ -39,6 +56,7 @@ type ChatMessage struct {
 	replyToUserData  *database.User
 	replyToChannel   *tele.Chat // Канал, на сообщение которого отвечают
 	adminRole        string
+	permissions      []string // Разрешения отправителя на админские команды
 	appeal           string
 	replyToID        int64
 	isFromChannel    bool // Флаг, указывающий, что сообщение от канала
@@ -137,6 +155,21 @@ func (cm *ChatMessage) AdminRole() string {
 	return cm.adminRole
 }
 
+func (cm *ChatMessage) Permissions() []string {
+	if cm == nil {
+		return nil
+	}
+	return cm.permissions
+}
+
+// HasPermission проверяет, может ли отправитель выполнить команду с этим разрешением
+func (cm *ChatMessage) HasPermission(perm string) bool {
+	if cm == nil {
+		return false
+	}
+	return slices.Contains(cm.permissions, perm)
+}
+
 func (cm *ChatMessage) Appeal() string {
 	if cm == nil {
 		return ""
@@ -221,12 +254,12 @@ func initChatMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessage,
 		threadID: msg.ThreadID,
 	}
 
-	// Определяем, является ли отправитель каналом-админом
+	// Определяем, является ли отправитель каналом-админом или анонимным админом, пишущим от имени группы
 	var chatAdmin bool
 	if msg.SenderChat != nil {
 		chatMsg.channel = msg.SenderChat
 		chatID := msg.SenderChat.ID
-		if slices.Contains(handler.AdminsList, chatID) {
+		if slices.Contains(handler.AdminsList, chatID) || chatID == msg.Chat.ID {
 			chatAdmin = true
 		}
 	}
@@ -284,6 +317,7 @@ func initChatMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessage,
 
 		// Обновляем username и firstname, если изменились
 		if userData.Username != msg.Sender.Username || userData.FirstName != msg.Sender.FirstName {
+			handler.Rep.RecordUsernameChange(userID, userData.Username, userData.FirstName, msg.Sender.Username, msg.Sender.FirstName)
 			userData.Username = msg.Sender.Username
 			userData.FirstName = msg.Sender.FirstName
 			if err := handler.Rep.SaveUser(&userData); err != nil {
@@ -314,8 +348,8 @@ func initChatMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessage,
 			}
 			chatMsg.replyToAppeal = replyToAppeal
 
-			// Проверяем, является ли канал админом
-			replyToAdmin := slices.Contains(handler.AdminsList, replyToChannelID)
+			// Проверяем, является ли канал админом или анонимным админом группы
+			replyToAdmin := slices.Contains(handler.AdminsList, replyToChannelID) || replyToChannelID == msg.Chat.ID
 			chatMsg.replyToAdmin = replyToAdmin
 		} else {
 			// ReplyTo - это пользователь
@@ -347,6 +381,7 @@ func initChatMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessage,
 			}
 
 			if replyToUser.Username != msg.ReplyTo.Sender.Username {
+				handler.Rep.RecordUsernameChange(replyToID, replyToUser.Username, replyToUser.FirstName, msg.ReplyTo.Sender.Username, replyToUser.FirstName)
 				replyToUser.Username = msg.ReplyTo.Sender.Username
 				if err := handler.Rep.SaveUser(&replyToUser); err != nil {
 					log.Printf("Failed to save persistent username update for reply user %d: %v", replyToID, err)
@@ -388,6 +423,8 @@ func initChatMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessage,
 		chatMsg.isWinner = isWinner
 	}
 
+	chatMsg.permissions = resolvePermissions(handler, chatMsg)
+
 	return chatMsg, nil
 }
 
@@ -442,6 +479,7 @@ func initPrivateMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessa
 
 	// Обновляем username и firstname, если изменились
 	if userData.Username != msg.Sender.Username || userData.FirstName != msg.Sender.FirstName {
+		handler.Rep.RecordUsernameChange(userID, userData.Username, userData.FirstName, msg.Sender.Username, msg.Sender.FirstName)
 		userData.Username = msg.Sender.Username
 		userData.FirstName = msg.Sender.FirstName
 		if err := handler.Rep.SaveUser(&userData); err != nil {
@@ -466,9 +504,53 @@ func initPrivateMessage(c tele.Context, handler *ChatMessageHandler) (*ChatMessa
 	isWinner := userData.UserID == handler.QuizManager.Winner()
 	chatMsg.isWinner = isWinner
 
+	chatMsg.permissions = resolvePermissions(handler, chatMsg)
+
 	return chatMsg, nil
 }
 
+// resolvePermissions определяет разрешения отправителя: каналы-админы и главный админ могут всё,
+// остальные админы получают разрешения своей роли, к ним добавляются разрешения действующих
+// временных ролей (победитель квиза, стажер и т.п.)
+func resolvePermissions(handler *ChatMessageHandler, chatMsg *ChatMessage) []string {
+	if chatMsg.chatAdmin {
+		// Анонимные админы и каналы-админы, превысившие лимиты, приостанавливаются по ID чата или канала
+		if handler.Rep.IsAdminSuspended(chatMsg.channel.ID) {
+			return nil
+		}
+		return database.AllPermissions
+	}
+	if chatMsg.userData == nil {
+		return nil
+	}
+	userID := chatMsg.userData.UserID
+	if handler.MainAdminID != 0 && userID == handler.MainAdminID {
+		return database.AllPermissions
+	}
+	// Приостановленный за превышение лимитов админ теряет все разрешения до решения главного админа
+	if handler.Rep.IsAdminSuspended(userID) {
+		return nil
+	}
+	var perms []string
+	if chatMsg.adminRole != "" {
+		var err error
+		perms, err = handler.Rep.GetAdminPermissions(userID)
+		if err != nil {
+			log.Printf("failed to get permissions for admin %d: %v", userID, err)
+		}
+	}
+	tempPerms, err := handler.Rep.GetTempRolePermissions(userID)
+	if err != nil {
+		log.Printf("failed to get temp role permissions for user %d: %v", userID, err)
+	}
+	for _, perm := range tempPerms {
+		if !slices.Contains(perms, perm) {
+			perms = append(perms, perm)
+		}
+	}
+	return perms
+}
+
 // GetUserState возвращает текущее состояние пользователя для личной переписки
 // Если состояние не установлено, возвращает "default"
 func (h *ChatMessageHandler) GetUserState(userID int64) string {
@@ -489,3 +571,17 @@ func (h *ChatMessageHandler) SetUserState(userID int64, state string) {
 	}
 	h.UserStates[userID] = state
 }
+
+// GetAdminsUsernames возвращает копию юзернеймов админов для вызова командой "админ"
+func (h *ChatMessageHandler) GetAdminsUsernames() []string {
+	h.adminsMu.RLock()
+	defer h.adminsMu.RUnlock()
+	return slices.Clone(h.AdminsUsernames)
+}
+
+// SetAdminsUsernames заменяет юзернеймы админов
+func (h *ChatMessageHandler) SetAdminsUsernames(usernames []string) {
+	h.adminsMu.Lock()
+	defer h.adminsMu.Unlock()
+	h.AdminsUsernames = usernames
+}
@@ -2,6 +2,8 @@ package handlers
 
 import (
 	"fmt"
+	"log"
+	"saxbot/database"
 	"saxbot/messages"
 	"strings"
 
@@ -25,6 +27,12 @@ func handleUserChatMessage(c tele.Context, chatMessageHandler *ChatMessageHandle
 		return handleWarns(c, chatMessageHandler)
 	case "гороскоп", "/horoscope":
 		return handleHoroscope(c, chatMessageHandler)
+	case "топ квиза", "/quiztop":
+		return handleQuizTop(c, chatMessageHandler)
+	}
+
+	if kind, ok := isVoteCommand(text); ok {
+		return handleStartVote(c, chatMessageHandler, kind)
 	}
 
 	normalized := strings.ReplaceAll(text, ",", " ")
@@ -59,6 +67,22 @@ func handleUserPrivateMessage(c tele.Context, chatMessageHandler *ChatMessageHan
 	case "/state":
 		currentState := chatMessageHandler.GetUserState(userID)
 		return messages.ReplyMessage(c, fmt.Sprintf("Текущее состояние: %s", currentState), chatMsg.ThreadID())
+	case "топ квиза", "/quiztop":
+		return handleQuizTop(c, chatMessageHandler)
+	}
+
+	// Ответ на вопрос анкеты при заявке на вступление. Команды ответом не считаются
+	if chatMessageHandler.GetUserState(userID) == joinAnswerState && !strings.HasPrefix(text, "/") {
+		request, err := chatMessageHandler.Rep.GetOpenJoinRequest(userID, database.JoinAsking)
+		if err != nil {
+			log.Printf("Failed to check join request of user %d: %v", userID, err)
+			return nil
+		}
+		if request != nil {
+			return handleJoinAnswer(c, chatMessageHandler, request)
+		}
+		// Заявку уже рассмотрели или она истекла
+		chatMessageHandler.SetUserState(userID, "default")
 	}
 
 	// Проверка на формат даты рождения (DD.MM.YYYY)
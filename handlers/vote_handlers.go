@@ -0,0 +1,309 @@
+package handlers
+
+import (
+	"fmt"
+	"log"
+	"saxbot/admins"
+	"saxbot/environment"
+	"saxbot/messages"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// Виды голосований
+const (
+	voteMute = "mute"
+	voteBan  = "ban"
+)
+
+// vote - голосование участников за мут или бан
+type vote struct {
+	ID         int
+	Kind       string
+	Chat       *tele.Chat
+	TargetID   int64
+	TargetName string
+	Quorum     int
+	Voters     map[int64]bool // userID -> голос "за"
+	Message    *tele.Message  // Сообщение бота с кнопками
+	Finished   bool
+}
+
+func (v *vote) count() (yes int, no int) {
+	for _, isYes := range v.Voters {
+		if isYes {
+			yes++
+		} else {
+			no++
+		}
+	}
+	return yes, no
+}
+
+// VoteManager хранит активные голосования
+type VoteManager struct {
+	Settings environment.VoteEnvironment
+
+	mu     sync.Mutex
+	nextID int
+	votes  map[int]*vote
+}
+
+func NewVoteManager(settings environment.VoteEnvironment) *VoteManager {
+	return &VoteManager{Settings: settings, votes: make(map[int]*vote)}
+}
+
+// start регистрирует голосование, если против этого пользователя в чате еще нет активного
+func (vm *VoteManager) start(v *vote) bool {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	for _, existing := range vm.votes {
+		if existing.Chat.ID == v.Chat.ID && existing.TargetID == v.TargetID {
+			return false
+		}
+	}
+	vm.nextID++
+	v.ID = vm.nextID
+	vm.votes[v.ID] = v
+	return true
+}
+
+// finish завершает голосование. Возвращает false, если оно уже завершено
+func (vm *VoteManager) finish(id int) (*vote, bool) {
+	vm.mu.Lock()
+	defer vm.mu.Unlock()
+	v, ok := vm.votes[id]
+	if !ok || v.Finished {
+		return nil, false
+	}
+	v.Finished = true
+	delete(vm.votes, id)
+	return v, true
+}
+
+// isVoteCommand возвращает вид голосования по тексту команды
+func isVoteCommand(text string) (string, bool) {
+	switch text {
+	case "голосуем за мут":
+		return voteMute, true
+	case "голосуем за бан":
+		return voteBan, true
+	}
+	return "", false
+}
+
+// canVote проверяет, может ли участник голосовать. Если нет, возвращает причину
+func canVote(chatMessageHandler *ChatMessageHandler, userID int64) (bool, string) {
+	if userID == chatMessageHandler.MainAdminID || chatMessageHandler.Rep.IsAdmin(userID) {
+		return true, ""
+	}
+	user, err := chatMessageHandler.Rep.GetUser(userID)
+	if err != nil {
+		return false, "Не нашел тебя в базе, попробуй позже"
+	}
+	if user.Status != "active" {
+		return false, "Сейчас ты не можешь голосовать"
+	}
+	settings := chatMessageHandler.Votes.Settings
+	if time.Since(user.CreatedAt) < settings.MinAge {
+		return false, fmt.Sprintf("Голосовать можно через %d дн. после первого сообщения в чате", int(settings.MinAge.Hours()/24))
+	}
+	if user.MessageCount < settings.MinMessages {
+		return false, fmt.Sprintf("Чтобы голосовать, нужно написать в чат хотя бы %d сообщений", settings.MinMessages)
+	}
+	return true, ""
+}
+
+// voteText формирует текст сообщения с голосованием
+func voteText(v *vote, window time.Duration, muteMinutes uint) string {
+	yes, no := v.count()
+	action := fmt.Sprintf("мут %s на %d минут", v.TargetName, muteMinutes)
+	if v.Kind == voteBan {
+		action = "бан " + v.TargetName
+	}
+	return fmt.Sprintf("Голосование за %s.\nНужно %d голосов \"за\" в течение %d минут, и их должно быть больше, чем \"против\".\nЗа: %d, против: %d",
+		action, v.Quorum, int(window.Minutes()), yes, no)
+}
+
+// voteMarkup формирует кнопки голосования
+func voteMarkup(v *vote) *tele.ReplyMarkup {
+	yes, no := v.count()
+	id := strconv.Itoa(v.ID)
+	menu := &tele.ReplyMarkup{}
+	btnYes := menu.Data(fmt.Sprintf("За (%d)", yes), "vote_yes_"+id)
+	btnNo := menu.Data(fmt.Sprintf("Против (%d)", no), "vote_no_"+id)
+	btnVeto := menu.Data("Вето (для админов)", "vote_veto_"+id)
+	menu.Inline(menu.Row(btnYes, btnNo), menu.Row(btnVeto))
+	return menu
+}
+
+// Обработка команды "голосуем за мут" или "голосуем за бан" ответом на сообщение
+func handleStartVote(c tele.Context, chatMessageHandler *ChatMessageHandler, kind string) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	settings := chatMessageHandler.Votes.Settings
+	if chatMsg.IsFromChannel() || chatMsg.Sender() == nil {
+		return nil
+	}
+	if !chatMsg.IsReply() {
+		return messages.ReplyMessage(c, "За кого голосуем? Ответь этой командой на сообщение нарушителя", chatMsg.ThreadID())
+	}
+	if kind == voteBan && settings.BanQuorum == 0 {
+		return messages.ReplyMessage(c, "Голосование за бан выключено, можно проголосовать только за мут", chatMsg.ThreadID())
+	}
+	if chatMsg.ReplyToAdmin() {
+		return messages.ReplyMessage(c, "Против админов не голосуют, соси писос", chatMsg.ThreadID())
+	}
+	if chatMsg.ReplyToIsChannel() || chatMsg.ReplyTo().Sender == nil || chatMsg.ReplyTo().Sender.IsBot {
+		return messages.ReplyMessage(c, "Голосовать можно только против участников чата", chatMsg.ThreadID())
+	}
+	initiatorID := chatMsg.Sender().ID
+	targetID := chatMsg.ReplyToID()
+	if targetID == initiatorID {
+		return messages.ReplyMessage(c, "Против себя голосовать не нужно, просто помолчи", chatMsg.ThreadID())
+	}
+	if ok, reason := canVote(chatMessageHandler, initiatorID); !ok {
+		return messages.ReplyMessage(c, reason, chatMsg.ThreadID())
+	}
+
+	v := &vote{
+		Kind:       kind,
+		Chat:       c.Chat(),
+		TargetID:   targetID,
+		TargetName: chatMsg.ReplyToAppeal(),
+		Quorum:     settings.MuteQuorum,
+		Voters:     map[int64]bool{initiatorID: true},
+	}
+	if kind == voteBan {
+		v.Quorum = settings.BanQuorum
+	}
+	if !chatMessageHandler.Votes.start(v) {
+		return messages.ReplyMessage(c, "Голосование против этого участника уже идет", chatMsg.ThreadID())
+	}
+
+	msg, err := chatMessageHandler.Bot.Reply(chatMsg.ReplyTo(), voteText(v, settings.Window, settings.MuteMinutes), &tele.SendOptions{
+		ReplyMarkup: voteMarkup(v),
+		ThreadID:    chatMsg.ThreadID(),
+	})
+	if err != nil {
+		chatMessageHandler.Votes.finish(v.ID)
+		return fmt.Errorf("failed to send vote message: %w", err)
+	}
+	chatMessageHandler.Votes.mu.Lock()
+	v.Message = msg
+	chatMessageHandler.Votes.mu.Unlock()
+	log.Printf("User %d started %s vote against %d in chat %d", initiatorID, kind, targetID, v.Chat.ID)
+
+	id := v.ID
+	time.AfterFunc(settings.Window, func() {
+		expired, ok := chatMessageHandler.Votes.finish(id)
+		if !ok {
+			return
+		}
+		yes, no := expired.count()
+		text := fmt.Sprintf("Голосование против %s не набрало нужных голосов. За: %d, против: %d", expired.TargetName, yes, no)
+		if _, err := chatMessageHandler.Bot.Edit(expired.Message, text); err != nil {
+			log.Printf("Failed to edit expired vote message: %v", err)
+		}
+	})
+	return nil
+}
+
+// handleVoteCallback обрабатывает кнопки голосования: vote_yes_<id>, vote_no_<id>, vote_veto_<id>
+func handleVoteCallback(c tele.Context, chatMessageHandler *ChatMessageHandler, callbackData string) error {
+	parts := strings.Split(callbackData, "_")
+	if len(parts) != 3 {
+		return c.Respond()
+	}
+	id, err := strconv.Atoi(parts[2])
+	if err != nil {
+		return c.Respond()
+	}
+	voterID := c.Callback().Sender.ID
+	votes := chatMessageHandler.Votes
+
+	if parts[1] == "veto" {
+		if voterID != chatMessageHandler.MainAdminID && !chatMessageHandler.Rep.IsAdmin(voterID) {
+			return c.Respond(&tele.CallbackResponse{Text: "Вето может наложить только админ"})
+		}
+		v, ok := votes.finish(id)
+		if !ok {
+			return c.Respond(&tele.CallbackResponse{Text: "Голосование уже завершено"})
+		}
+		c.Respond(&tele.CallbackResponse{Text: "Голосование отменено"})
+		log.Printf("Admin %d vetoed %s vote against %d", voterID, v.Kind, v.TargetID)
+		_, err := chatMessageHandler.Bot.Edit(v.Message, fmt.Sprintf("Админ отменил голосование против %s", v.TargetName))
+		return err
+	}
+
+	votes.mu.Lock()
+	v, ok := votes.votes[id]
+	if !ok || v.Finished || v.Message == nil {
+		votes.mu.Unlock()
+		return c.Respond(&tele.CallbackResponse{Text: "Голосование уже завершено"})
+	}
+	if voterID == v.TargetID {
+		votes.mu.Unlock()
+		return c.Respond(&tele.CallbackResponse{Text: "За себя голосовать нельзя"})
+	}
+	if _, voted := v.Voters[voterID]; voted {
+		votes.mu.Unlock()
+		return c.Respond(&tele.CallbackResponse{Text: "Ты уже проголосовал"})
+	}
+	votes.mu.Unlock()
+
+	if ok, reason := canVote(chatMessageHandler, voterID); !ok {
+		return c.Respond(&tele.CallbackResponse{Text: reason, ShowAlert: true})
+	}
+
+	votes.mu.Lock()
+	if v.Finished {
+		votes.mu.Unlock()
+		return c.Respond(&tele.CallbackResponse{Text: "Голосование уже завершено"})
+	}
+	v.Voters[voterID] = parts[1] == "yes"
+	yes, no := v.count()
+	passed := yes >= v.Quorum && yes > no
+	text := voteText(v, votes.Settings.Window, votes.Settings.MuteMinutes)
+	markup := voteMarkup(v)
+	votes.mu.Unlock()
+	c.Respond(&tele.CallbackResponse{Text: "Голос учтен"})
+
+	if !passed {
+		_, err := chatMessageHandler.Bot.Edit(v.Message, text, markup)
+		return err
+	}
+	if _, ok := votes.finish(id); !ok {
+		return nil
+	}
+	return applyVote(chatMessageHandler, v, yes, no)
+}
+
+// applyVote применяет наказание по итогам голосования
+func applyVote(chatMessageHandler *ChatMessageHandler, v *vote, yes int, no int) error {
+	settings := chatMessageHandler.Votes.Settings
+	member := &tele.ChatMember{User: &tele.User{ID: v.TargetID}, Role: tele.Member}
+	var text string
+	switch v.Kind {
+	case voteBan:
+		admins.BanUser(chatMessageHandler.Bot, v.Chat, member, chatMessageHandler.Rep)
+		text = fmt.Sprintf("Чат решил: %s идет нахуй из чатика. За: %d, против: %d", v.TargetName, yes, no)
+	default:
+		admins.MuteUser(chatMessageHandler.Bot, v.Chat, member, chatMessageHandler.Rep, settings.MuteMinutes)
+		text = fmt.Sprintf("Чат решил: %s помолчит %d минут. За: %d, против: %d", v.TargetName, settings.MuteMinutes, yes, no)
+	}
+	log.Printf("Vote %s against %d in chat %d passed: %d yes, %d no", v.Kind, v.TargetID, v.Chat.ID, yes, no)
+	// Наказание по голосованию не проходит через админа, поэтому итог отправляется главному админу
+	report := fmt.Sprintf("Итог голосования в чате %d против %s (ID %d):\n%s", v.Chat.ID, v.TargetName, v.TargetID, text)
+	if _, err := chatMessageHandler.Bot.Send(&tele.User{ID: chatMessageHandler.MainAdminID}, report); err != nil {
+		log.Printf("Failed to send vote result to main admin: %v", err)
+	}
+	_, err := chatMessageHandler.Bot.Edit(v.Message, text)
+	return err
+}
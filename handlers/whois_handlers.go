@@ -0,0 +1,250 @@
+package handlers
+
+import (
+	"fmt"
+	"html"
+	"log"
+	"saxbot/admins"
+	"saxbot/database"
+	"saxbot/messages"
+	"strconv"
+	"strings"
+
+	tele "gopkg.in/telebot.v4"
+)
+
+// canSeeDossier проверяет, может ли отправитель смотреть карточки пользователей: только админы с ролью
+// (не приостановленные) и главный админ. Владельцам одних лишь временных ролей карточки недоступны
+func canSeeDossier(chatMessageHandler *ChatMessageHandler) bool {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil || chatMsg.Sender() == nil {
+		return false
+	}
+	if chatMsg.Sender().ID == chatMessageHandler.MainAdminID {
+		return true
+	}
+	return chatMsg.AdminRole() != "" && len(chatMsg.Permissions()) > 0
+}
+
+// Обработка команды "кто это" ответом на сообщение в чате: карточка уходит админу в личку
+func handleWhoisReply(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if chatMsg.ChatAdmin() {
+		return messages.ReplyMessage(c, "Карточку пользователя отправляю в личку, а анонимным админам и каналам писать некуда", chatMsg.ThreadID())
+	}
+	if !canSeeDossier(chatMessageHandler) {
+		return nil
+	}
+	if !chatMsg.IsReply() || chatMsg.ReplyToIsChannel() {
+		return messages.ReplyMessage(c, "Про кого рассказать? Ответь на сообщение пользователя", chatMsg.ThreadID())
+	}
+
+	user, err := chatMessageHandler.Rep.FindUser(chatMsg.ReplyToID())
+	if err != nil {
+		log.Printf("Failed to find user %d: %v", chatMsg.ReplyToID(), err)
+		return messages.ReplyMessage(c, "Внутренняя ошибка базы данных. Попробуй еще раз", chatMsg.ThreadID())
+	}
+	if user == nil {
+		return messages.ReplyMessage(c, "Пользователь не найден", chatMsg.ThreadID())
+	}
+	card := buildUserDossier(chatMessageHandler, user)
+	// Карточка не должна попасть на глаза обычным пользователям: команду удаляем, карточку шлем в личку
+	chatMessageHandler.Bot.Delete(c.Message())
+	if _, err := chatMessageHandler.Bot.Send(chatMsg.Sender(), card, &tele.SendOptions{ParseMode: tele.ModeHTML}); err != nil {
+		log.Printf("Failed to send dossier to admin %d: %v", chatMsg.Sender().ID, err)
+	}
+	return nil
+}
+
+// Обработка команды "/whois <id или @username>" в личных сообщениях
+func handleWhoisCommand(c tele.Context, chatMessageHandler *ChatMessageHandler) error {
+	chatMsg := chatMessageHandler.ChatMessage
+	if chatMsg == nil {
+		return fmt.Errorf("chat message is nil")
+	}
+	if !canSeeDossier(chatMessageHandler) {
+		return c.Send("Карточки пользователей доступны только админам.")
+	}
+
+	text := strings.TrimSpace(chatMsg.Text())
+	var query string
+	if strings.HasPrefix(strings.ToLower(text), "кто это") {
+		query = strings.TrimSpace(text[len("кто это"):])
+	} else {
+		parts := strings.Fields(text)
+		if len(parts) > 1 {
+			query = parts[1]
+		}
+	}
+	if query == "" {
+		return c.Send("Не распознал команду. Вводи четко в формате \"/whois [id или @username]\"")
+	}
+
+	userID, err := resolveUserQuery(chatMessageHandler, query)
+	if err != nil {
+		log.Printf("Failed to resolve user %s: %v", query, err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if userID == 0 {
+		return c.Send(fmt.Sprintf("Пользователь %s не найден в базе", query))
+	}
+
+	user, err := chatMessageHandler.Rep.FindUser(userID)
+	if err != nil {
+		log.Printf("Failed to find user %d: %v", userID, err)
+		return c.Send("Внутренняя ошибка базы данных. Попробуй еще раз")
+	}
+	if user == nil {
+		return c.Send("Пользователь не найден")
+	}
+	card := buildUserDossier(chatMessageHandler, user)
+	return c.Send(card, &tele.SendOptions{ParseMode: tele.ModeHTML})
+}
+
+// resolveUserQuery находит ID пользователя по числовому ID или username (в том числе прошлому),
+// 0 - пользователя нет в базе
+func resolveUserQuery(chatMessageHandler *ChatMessageHandler, query string) (int64, error) {
+	if userID, err := strconv.ParseInt(query, 10, 64); err == nil {
+		user, err := chatMessageHandler.Rep.FindUser(userID)
+		if err != nil || user == nil {
+			return 0, err
+		}
+		return user.UserID, nil
+	}
+	username := strings.TrimPrefix(query, "@")
+	user, err := chatMessageHandler.Rep.GetUserByUsername(username)
+	if err != nil {
+		return 0, err
+	}
+	if user != nil {
+		return user.UserID, nil
+	}
+	return chatMessageHandler.Rep.GetUserIDByPastUsername(username)
+}
+
+// buildUserDossier собирает карточку пользователя для админов
+func buildUserDossier(chatMessageHandler *ChatMessageHandler, user *database.User) string {
+	rep := chatMessageHandler.Rep
+	userID := user.UserID
+
+	var sb strings.Builder
+	name := user.FirstName
+	if user.Username != "" {
+		name = fmt.Sprintf("%s (@%s)", user.FirstName, user.Username)
+	}
+	sb.WriteString(fmt.Sprintf("<b>Кто это: %s</b>\n", html.EscapeString(name)))
+	sb.WriteString(fmt.Sprintf("Telegram ID: <code>%d</code>\n", user.UserID))
+
+	history, err := rep.GetUsernameHistory(userID)
+	if err != nil {
+		log.Printf("failed to get username history of user %d: %v", userID, err)
+	}
+	if len(history) > 1 {
+		sb.WriteString("История имен:\n")
+		for _, entry := range history {
+			entryName := entry.FirstName
+			if entry.Username != "" {
+				entryName = fmt.Sprintf("%s (@%s)", entry.FirstName, entry.Username)
+			}
+			sb.WriteString(fmt.Sprintf("  %s — %s\n", entry.CreatedAt.In(database.MoscowTZ).Format("02.01.2006"), html.EscapeString(entryName)))
+		}
+	}
+
+	sb.WriteString(fmt.Sprintf("Впервые замечен: %s\n", user.CreatedAt.In(database.MoscowTZ).Format("02.01.2006")))
+	sb.WriteString(fmt.Sprintf("Сообщений: %d\n", user.MessageCount))
+	sb.WriteString(fmt.Sprintf("Предупреждений: %d\n", user.Warns))
+
+	// Текущие наказания
+	var sanctions []string
+	switch user.Status {
+	case "muted":
+		if user.MutedUntil.IsZero() {
+			sanctions = append(sanctions, "мут")
+		} else {
+			sanctions = append(sanctions, fmt.Sprintf("мут до %s", user.MutedUntil.In(database.MoscowTZ).Format("02.01.2006 15:04")))
+		}
+	case "restricted":
+		restriction := fmt.Sprintf("рестрикт (%s)", admins.RestrictPresetTitle(user.RestrictPreset))
+		if user.RestrictedUntil != nil {
+			restriction += " до " + user.RestrictedUntil.In(database.MoscowTZ).Format("02.01.2006 15:04")
+		}
+		sanctions = append(sanctions, restriction)
+	case "banned":
+		sanctions = append(sanctions, "бан")
+	}
+	if entry, err := rep.GetBlocklistEntry(userID); err != nil {
+		log.Printf("failed to check blocklist for user %d: %v", userID, err)
+	} else if entry != nil {
+		sanctions = append(sanctions, fmt.Sprintf("блоклист (%s)", html.EscapeString(entry.Reason)))
+	}
+	if len(sanctions) == 0 {
+		sb.WriteString("Наказания: нет\n")
+	} else {
+		sb.WriteString(fmt.Sprintf("Наказания: %s\n", strings.Join(sanctions, ", ")))
+	}
+
+	if user.Quarantined {
+		var terms []string
+		if user.QuarantineUntil != nil {
+			terms = append(terms, "до "+user.QuarantineUntil.In(database.MoscowTZ).Format("02.01.2006 15:04"))
+		}
+		if limit := chatMessageHandler.Quarantine.Messages; limit > 0 {
+			terms = append(terms, fmt.Sprintf("сообщений %d из %d", user.QuarantineMessages, limit))
+		}
+		sb.WriteString(fmt.Sprintf("Карантин новичка: %s\n", strings.Join(terms, ", ")))
+	}
+
+	if roles, err := rep.GetActiveTempRoles(userID); err != nil {
+		log.Printf("failed to get temp roles of user %d: %v", userID, err)
+	} else {
+		for _, role := range roles {
+			until := "до отзыва"
+			if role.ExpiresAt != nil {
+				until = "до " + role.ExpiresAt.In(database.MoscowTZ).Format("02.01.2006 15:04")
+			}
+			sb.WriteString(fmt.Sprintf("Временная роль: %s (%s), %s\n", role.Kind, strings.Join(role.PermissionList(), ", "), until))
+		}
+	}
+
+	wins, err := rep.CountQuizWins(userID)
+	if err != nil {
+		log.Printf("failed to count quiz wins of user %d: %v", userID, err)
+	}
+	sb.WriteString(fmt.Sprintf("Побед в квизе: %d\n", wins))
+
+	if user.Birthday.IsZero() {
+		sb.WriteString("День рождения указан: нет\n")
+	} else {
+		sb.WriteString("День рождения указан: да\n")
+	}
+
+	switch {
+	case user.VerifiedAt != nil:
+		sb.WriteString(fmt.Sprintf("Проверку при входе прошел: да, %s\n", user.VerifiedAt.In(database.MoscowTZ).Format("02.01.2006 15:04")))
+	case user.Status == "new_user":
+		sb.WriteString("Проверку при входе прошел: нет, ждет нажатия кнопки\n")
+	case user.Status == "held":
+		sb.WriteString("Проверку при входе прошел: нет, подозрительный профиль ждет решения админа\n")
+	default:
+		sb.WriteString("Проверку при входе прошел: нет данных\n")
+	}
+
+	if rep.IsAdmin(userID) {
+		role, _ := rep.GetAdminRole(userID)
+		sb.WriteString(fmt.Sprintf("Админ, роль: %s\n", role))
+	}
+
+	notes, err := rep.GetUserNotes(userID)
+	if err != nil {
+		log.Printf("failed to get notes about user %d: %v", userID, err)
+	}
+	if len(notes) > 0 {
+		sb.WriteString("\n<b>Заметки админов:</b>\n")
+		sb.WriteString(html.EscapeString(formatNotes(chatMessageHandler, notes, "")))
+	}
+
+	return sb.String()
+}
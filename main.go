@@ -11,6 +11,7 @@ import (
 	"saxbot/environment"
 	"saxbot/handlers"
 	"saxbot/parser"
+	"saxbot/spam"
 	"strconv"
 	"strings"
 
@@ -80,13 +81,22 @@ func main() {
 	}
 
 	// Управление квизом
+	quizEnv := environment.GetQuizEnvironment()
 	quizManager := &activities.QuizManager{
 		TodayQuiz:      activities.QuoteQuiz{},
 		QuizRunning:    false,
 		QuizAlreadyWas: false,
 		WinnerID:       0,
-		IsLastQuizClip: false,
+		LastQuizKind:   "",
 		QuizChatID:     quizChatID,
+		AllowedChats:   allowedChats,
+		HintAfter:      quizEnv.HintAfter,
+		Deadline:       quizEnv.Deadline,
+		PollDuration:   quizEnv.PollDuration,
+		Rotation: activities.QuizRotation{
+			ItemWindow: quizEnv.RepeatAfter,
+			SongWindow: quizEnv.SongRepeat,
+		},
 	}
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
@@ -97,6 +107,11 @@ func main() {
 
 	go StartPostCooldown(ctx, postGate, postDone, 20*time.Minute)
 
+	// Встроенные цитаты и кадры переносятся в базу при первом запуске
+	if err := activities.ImportQuizItems(rep); err != nil {
+		log.Printf("failed to import quiz items: %v", err)
+	}
+
 	go activities.ManageQuiz(rep, bot, quizManager, postGate, postDone)
 
 	// Даем секунду менеджеру квизов, чтоб определить при перезапуске бота, идет квиз или нет
@@ -112,12 +127,25 @@ func main() {
 	// Управление "треком дня"
 	go activities.ManageTrackOfTheDay(bot, quizManager, rep, postGate, postDone)
 
+	// Подведение итогов месячных сезонов квиза
+	go activities.ManageQuizSeasons(bot, rep, quizManager, postGate, postDone)
+
 	chat := &tele.Chat{ID: quizChatID}
 
-	// Размут пользователей по таймеру
+	// Размут пользователей, снятие рестриктов и карантина новичков по таймеру
 	go func() {
 		for {
 			admins.UnmuteUsersByTime(bot, chat, rep)
+			admins.UnrestrictUsersByTime(bot, chat, rep)
+			admins.ReleaseQuarantinesByTime(bot, chat, rep)
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// Снятие временных ролей с истекшим сроком
+	go func() {
+		for {
+			admins.RevokeExpiredTempRoles(bot, allowedChats, rep)
 			time.Sleep(time.Minute)
 		}
 	}()
@@ -132,6 +160,17 @@ func main() {
 		}
 	}()
 
+	// Антиспам
+	spamEnv := environment.GetSpamEnvironment()
+	spamEngine := spam.NewEngine(spam.DefaultSignals(spamEnv.Phrases), spam.Bands{
+		Delete: spamEnv.DeleteScore,
+		Mute:   spamEnv.MuteScore,
+		Ban:    spamEnv.BanScore,
+	}, spamEnv.DryRun)
+
+	// Проверка профилей при входе
+	screenEnv := environment.GetScreenEnvironment()
+
 	// Инициализация обработчика сообщений чата
 	chatMessageHandler := handlers.ChatMessageHandler{
 		AllowedChats:    allowedChats,
@@ -140,10 +179,90 @@ func main() {
 		QuizManager:     quizManager,
 		Rep:             rep,
 		Bot:             bot,
+		MainAdminID:     mainEnv.MainAdminID,
+		Limits:          environment.GetLimitsEnvironment(),
 		// KatyaID:         mainEnv.KatyaID,
-		UserStates: make(map[int64]string),
+		UserStates:   make(map[int64]string),
+		Messages:     handlers.NewMessageIndex(mainEnv.MessageIndexSize),
+		Archive:      environment.GetArchiveEnvironment(),
+		Votes:        handlers.NewVoteManager(environment.GetVoteEnvironment()),
+		Spam:         spamEngine,
+		SpamSettings: spamEnv,
+		Screener:     spam.NewScreener(screenEnv.Patterns, screenEnv.ProtectedNames),
+		ScreenAction: screenEnv.Action,
+		JoinSettings: environment.GetJoinEnvironment(),
+		Quarantine:   environment.GetQuarantineEnvironment(),
 	}
 
+	// Заявители, не ответившие на вопрос анкеты до перезапуска, снова могут ответить в личке
+	handlers.RestoreJoinStates(&chatMessageHandler)
+
+	// Сверка админов с администраторами чатов
+	go func() {
+		for {
+			handlers.SyncAdmins(&chatMessageHandler)
+			time.Sleep(mainEnv.AdminSyncInterval)
+		}
+	}()
+
+	// Отклонение нерассмотренных заявок на вступление и открытие чатов по истечении срока закрытия
+	go func() {
+		for {
+			handlers.ExpireJoinRequests(&chatMessageHandler)
+			handlers.LiftExpiredLockdowns(&chatMessageHandler)
+			time.Sleep(time.Minute)
+		}
+	}()
+
+	// Сверка наказаний в базе с правами пользователей в Telegram
+	go func() {
+		for {
+			time.Sleep(mainEnv.ReconcileInterval)
+			handlers.ReconcileSanctions(&chatMessageHandler)
+		}
+	}()
+
+	// Удаление старых сообщений из архива
+	go func() {
+		for {
+			retention := chatMessageHandler.Archive.Retention
+			deleted, err := rep.PurgeArchive(time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("failed to purge message archive: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Purged %d archived messages older than %v", deleted, retention)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	// Удаление старых решений антиспама
+	go func() {
+		for {
+			retention := chatMessageHandler.SpamSettings.LogRetention
+			deleted, err := rep.PurgeSpamDecisions(time.Now().Add(-retention))
+			if err != nil {
+				log.Printf("failed to purge spam decisions: %v", err)
+			} else if deleted > 0 {
+				log.Printf("Purged %d spam decisions older than %v", deleted, retention)
+			}
+			time.Sleep(time.Hour)
+		}
+	}()
+
+	// Еженедельный отчет по модерации главному админу (понедельник, 10:00 по Москве)
+	go func() {
+		for {
+			now := time.Now().In(database.MoscowTZ)
+			next := time.Date(now.Year(), now.Month(), now.Day(), 10, 0, 0, 0, database.MoscowTZ)
+			for next.Weekday() != time.Monday || !next.After(now) {
+				next = next.AddDate(0, 0, 1)
+			}
+			time.Sleep(time.Until(next))
+			handlers.SendWeeklyAdminStats(&chatMessageHandler)
+		}
+	}()
+
 	// Обработка текстовых сообщений
 	bot.Handle(tele.OnText, func(c tele.Context) error {
 		if c.Chat().Type == tele.ChatPrivate {
@@ -157,14 +276,43 @@ func main() {
 		return handlers.HandleUserJoined(c, &chatMessageHandler)
 	})
 
+	// Обработка заявок на вступление в чат
+	bot.Handle(tele.OnChatJoinRequest, func(c tele.Context) error {
+		return handlers.HandleJoinRequest(c, &chatMessageHandler)
+	})
+
+	// Обработка выхода пользователей из чата
+	bot.Handle(tele.OnUserLeft, func(c tele.Context) error {
+		return handlers.HandleUserLeft(c, &chatMessageHandler)
+	})
+
 	// Обработка колбэков от инлайн-меню
 	bot.Handle(tele.OnCallback, func(c tele.Context) error {
 		return handlers.HandleCallback(c, &chatMessageHandler)
 	})
 
-	// Сохранение трека в базу (только для главного админа)
+	// Ответы на квиз-опрос
+	bot.Handle(tele.OnPollAnswer, func(c tele.Context) error {
+		return handlers.HandlePollAnswer(c, &chatMessageHandler)
+	})
+
+	// Медиа в чатах запоминаются для зачистки
+	bot.Handle(tele.OnMedia, func(c tele.Context) error {
+		return handlers.HandleChatMedia(c, &chatMessageHandler)
+	})
+
+	// Фотографии в чатах запоминаются для зачистки, в личке главного админа добавляются в квиз
+	bot.Handle(tele.OnPhoto, func(c tele.Context) error {
+		if c.Chat().Type == tele.ChatPrivate {
+			return handlers.HandlePrivatePhoto(c, &chatMessageHandler)
+		}
+		return handlers.HandleChatMedia(c, &chatMessageHandler)
+	})
+
+	// Сохранение трека в базу (главный админ и админы с разрешением на каталог)
 	bot.Handle(tele.OnAudio, func(c tele.Context) error {
-		if c.Sender().ID != mainEnv.MainAdminID {
+		handlers.HandleChatMedia(c, &chatMessageHandler)
+		if c.Sender().ID != mainEnv.MainAdminID && !rep.AdminHasPermission(c.Sender().ID, database.PermManageCatalog) {
 			return nil
 		}
 		audio := c.Message().Audio
@@ -201,6 +349,14 @@ func main() {
 		return c.Reply("Трек сохранен")
 	})
 
+	// Импорт блоклиста файлом в личных сообщениях
+	bot.Handle(tele.OnDocument, func(c tele.Context) error {
+		if c.Chat().Type != tele.ChatPrivate {
+			return handlers.HandleChatMedia(c, &chatMessageHandler)
+		}
+		return handlers.HandlePrivateDocument(c, &chatMessageHandler)
+	})
+
 	bot.Handle(tele.OnChannelPost, func(c tele.Context) error {
 		return handlers.HandleChannelPost(c, &chatMessageHandler)
 	})
@@ -0,0 +1,96 @@
+package spam
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Действия по итогам оценки сообщения
+const (
+	ActionAllow  = "allow"  // Пропустить
+	ActionDelete = "delete" // Молча удалить
+	ActionMute   = "mute"   // Удалить и замутить автора
+	ActionBan    = "ban"    // Удалить и забанить автора
+)
+
+// Input - данные сообщения и его автора, по которым считаются сигналы
+type Input struct {
+	Text                 string   // Текст или подпись к медиа
+	SenderNames          []string // Имя, фамилия и username автора
+	IsNewcomer           bool     // Автор недавно в чате
+	ForwardedFromChannel bool     // Сообщение переслано из канала
+	URLs                 int      // Количество ссылок
+	Mentions             int      // Количество упоминаний других пользователей
+}
+
+// Signal - один признак спама. Score возвращает баллы (0, если признак не сработал)
+type Signal interface {
+	Name() string
+	Score(in Input) int
+}
+
+// Bands - пороги баллов для действий (0 - действие выключено)
+type Bands struct {
+	Delete int
+	Mute   int
+	Ban    int
+}
+
+// Hit - сработавший сигнал и его баллы
+type Hit struct {
+	Signal string
+	Points int
+}
+
+// Decision - итог оценки сообщения
+type Decision struct {
+	Score  int
+	Action string
+	Hits   []Hit
+	DryRun bool // Действие только записано, но не применено
+}
+
+// Summary возвращает сработавшие сигналы в виде "urls:20, phrases:25"
+func (d Decision) Summary() string {
+	parts := make([]string, 0, len(d.Hits))
+	for _, hit := range d.Hits {
+		parts = append(parts, fmt.Sprintf("%s:%d", hit.Signal, hit.Points))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Engine складывает баллы сигналов и выбирает действие по порогам
+type Engine struct {
+	Signals []Signal
+	Bands   Bands
+	DryRun  bool // Только записывать решения, ничего не удалять и не наказывать
+}
+
+func NewEngine(signals []Signal, bands Bands, dryRun bool) *Engine {
+	return &Engine{Signals: signals, Bands: bands, DryRun: dryRun}
+}
+
+// Evaluate оценивает сообщение
+func (e *Engine) Evaluate(in Input) Decision {
+	decision := Decision{Action: ActionAllow, DryRun: e.DryRun}
+	for _, signal := range e.Signals {
+		points := signal.Score(in)
+		if points == 0 {
+			continue
+		}
+		decision.Score += points
+		decision.Hits = append(decision.Hits, Hit{Signal: signal.Name(), Points: points})
+	}
+	sort.Slice(decision.Hits, func(i, j int) bool { return decision.Hits[i].Points > decision.Hits[j].Points })
+
+	switch {
+	case e.Bands.Ban > 0 && decision.Score >= e.Bands.Ban:
+		decision.Action = ActionBan
+	case e.Bands.Mute > 0 && decision.Score >= e.Bands.Mute:
+		decision.Action = ActionMute
+	case e.Bands.Delete > 0 && decision.Score >= e.Bands.Delete:
+		decision.Action = ActionDelete
+	}
+	return decision
+}
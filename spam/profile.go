@@ -0,0 +1,130 @@
+package spam
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"saxbot/textdist"
+	"strings"
+	"unicode"
+)
+
+// Шаблоны имен, типичные для спам-аккаунтов
+var defaultProfilePatterns = []string{
+	`крипт|crypto|btc|usdt|bitcoin|биткоин`,
+	`трейд|trading|инвест|invest|заработ|доход`,
+	`прода[мюжё]|купл|скидк`,
+	`казино|casino|ставк|беттинг`,
+	`onlyfans|18\+|интим|эскорт|escort|знакомств`,
+}
+
+// Profile - имя пользователя для проверки при входе
+type Profile struct {
+	FirstName string
+	LastName  string
+	Username  string
+}
+
+// Screener проверяет профили входящих пользователей
+type Screener struct {
+	Patterns  []*regexp.Regexp
+	Protected []string // Имена, под которые нельзя маскироваться (артисты, проект)
+}
+
+// NewScreener создает проверку профилей. extraPatterns дополняют шаблоны по умолчанию,
+// protected - имена, под которые нельзя маскироваться, помимо админов
+func NewScreener(extraPatterns []string, protected []string) *Screener {
+	screener := &Screener{}
+	for _, pattern := range append(append([]string{}, defaultProfilePatterns...), extraPatterns...) {
+		pattern = strings.TrimSpace(pattern)
+		if pattern == "" {
+			continue
+		}
+		re, err := regexp.Compile("(?i)" + pattern)
+		if err != nil {
+			log.Printf("Invalid profile screening pattern %q: %v", pattern, err)
+			continue
+		}
+		screener.Patterns = append(screener.Patterns, re)
+	}
+	for _, name := range protected {
+		if name = strings.TrimSpace(name); name != "" {
+			screener.Protected = append(screener.Protected, name)
+		}
+	}
+	return screener
+}
+
+// Screen возвращает причины, по которым профиль подозрителен (пусто - профиль в порядке).
+// admins - имена и юзернеймы текущих админов
+func (s *Screener) Screen(profile Profile, admins []string) []string {
+	var reasons []string
+	fullName := strings.TrimSpace(profile.FirstName + " " + profile.LastName)
+	fields := []string{profile.FirstName, profile.LastName, profile.Username}
+
+	for _, re := range s.Patterns {
+		for _, field := range fields {
+			if match := re.FindString(field); match != "" {
+				reasons = append(reasons, fmt.Sprintf("подозрительное слово в имени: %q", match))
+				break
+			}
+		}
+	}
+
+	if fullName != "" && !strings.ContainsFunc(fullName, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) }) {
+		reasons = append(reasons, "имя без букв (только эмодзи или символы)")
+	}
+
+	for _, field := range fields {
+		if strings.ContainsFunc(field, isInvisibleRune) {
+			reasons = append(reasons, "невидимые символы или смена направления текста в имени")
+			break
+		}
+	}
+
+	protected := append(append([]string{}, s.Protected...), admins...)
+	for _, candidate := range []string{profile.Username, fullName, profile.FirstName} {
+		if name, ok := lookAlike(candidate, protected); ok {
+			reasons = append(reasons, fmt.Sprintf("имя похоже на %q", name))
+			break
+		}
+	}
+	return reasons
+}
+
+// Похожие на латиницу символы кириллицы и цифры
+var confusables = strings.NewReplacer(
+	"а", "a", "в", "b", "е", "e", "ё", "e", "к", "k", "м", "m", "н", "h", "о", "o",
+	"р", "p", "с", "c", "т", "t", "у", "y", "х", "x", "і", "l", "ј", "j",
+	"0", "o", "1", "l", "3", "e", "4", "a", "5", "s", "7", "t", "|", "l",
+	"i", "l",
+)
+
+// Skeleton приводит имя к виду для сравнения похожих написаний: нижний регистр, латиница вместо похожей кириллицы, только буквы
+func Skeleton(name string) string {
+	name = confusables.Replace(strings.ToLower(name))
+	return strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) {
+			return r
+		}
+		return -1
+	}, name)
+}
+
+// lookAlike проверяет, маскируется ли имя под одно из защищенных
+func lookAlike(candidate string, protected []string) (string, bool) {
+	skeleton := Skeleton(candidate)
+	if len([]rune(skeleton)) < 4 {
+		return "", false
+	}
+	for _, name := range protected {
+		target := Skeleton(name)
+		if len([]rune(target)) < 4 {
+			continue
+		}
+		if skeleton == target || (len([]rune(target)) >= 6 && textdist.Levenshtein(skeleton, target) <= 1) {
+			return name, true
+		}
+	}
+	return "", false
+}
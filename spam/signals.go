@@ -0,0 +1,181 @@
+package spam
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Фразы, типичные для спама в чате
+var defaultPhrases = []string{
+	"пассивный доход",
+	"заработок",
+	"заработать",
+	"доход от",
+	"в лс",
+	"в личку",
+	"пишите в личные",
+	"набираю в команду",
+	"ищу людей",
+	"удаленная работа",
+	"удалённая работа",
+	"без вложений",
+	"инвестиции",
+	"криптовалют",
+	"арбитраж",
+	"ставки на спорт",
+	"интим",
+	"казино",
+}
+
+// DefaultSignals возвращает набор сигналов по умолчанию. extraPhrases дополняют список спам-фраз
+func DefaultSignals(extraPhrases []string) []Signal {
+	phrases := append([]string{}, defaultPhrases...)
+	for _, phrase := range extraPhrases {
+		phrase = strings.ToLower(strings.TrimSpace(phrase))
+		if phrase != "" {
+			phrases = append(phrases, phrase)
+		}
+	}
+	return []Signal{
+		NewcomerSignal{Points: 20},
+		ScriptSignal{Points: 20, MinLetters: 10, Ratio: 0.7},
+		URLSignal{Points: 20, PerExtra: 10, Max: 40},
+		ForwardSignal{Points: 25},
+		MentionSignal{Points: 5, Max: 25},
+		PhraseSignal{Phrases: phrases, Points: 25, Max: 50},
+		NameSignal{Points: 30},
+	}
+}
+
+// NewcomerSignal - автор недавно в чате
+type NewcomerSignal struct {
+	Points int
+}
+
+func (s NewcomerSignal) Name() string { return "newcomer" }
+
+func (s NewcomerSignal) Score(in Input) int {
+	if in.IsNewcomer {
+		return s.Points
+	}
+	return 0
+}
+
+// ScriptSignal - большая доля букв не кириллицей
+type ScriptSignal struct {
+	Points     int
+	MinLetters int     // Короткие сообщения не проверяем
+	Ratio      float64 // С какой доли "чужих" букв сигнал срабатывает
+}
+
+func (s ScriptSignal) Name() string { return "script" }
+
+func (s ScriptSignal) Score(in Input) int {
+	letters, foreign := 0, 0
+	for _, r := range in.Text {
+		if !unicode.IsLetter(r) {
+			continue
+		}
+		letters++
+		if !unicode.Is(unicode.Cyrillic, r) {
+			foreign++
+		}
+	}
+	if letters < s.MinLetters || float64(foreign)/float64(letters) < s.Ratio {
+		return 0
+	}
+	return s.Points
+}
+
+// URLSignal - ссылки в сообщении
+type URLSignal struct {
+	Points   int // За первую ссылку
+	PerExtra int // За каждую следующую
+	Max      int
+}
+
+func (s URLSignal) Name() string { return "urls" }
+
+func (s URLSignal) Score(in Input) int {
+	if in.URLs == 0 {
+		return 0
+	}
+	return min(s.Points+(in.URLs-1)*s.PerExtra, s.Max)
+}
+
+// ForwardSignal - сообщение переслано из канала
+type ForwardSignal struct {
+	Points int
+}
+
+func (s ForwardSignal) Name() string { return "forward" }
+
+func (s ForwardSignal) Score(in Input) int {
+	if in.ForwardedFromChannel {
+		return s.Points
+	}
+	return 0
+}
+
+// MentionSignal - упоминания других пользователей
+type MentionSignal struct {
+	Points int // За каждое упоминание
+	Max    int
+}
+
+func (s MentionSignal) Name() string { return "mentions" }
+
+func (s MentionSignal) Score(in Input) int {
+	return min(in.Mentions*s.Points, s.Max)
+}
+
+// PhraseSignal - известные спам-фразы
+type PhraseSignal struct {
+	Phrases []string
+	Points  int // За каждую найденную фразу
+	Max     int
+}
+
+func (s PhraseSignal) Name() string { return "phrases" }
+
+func (s PhraseSignal) Score(in Input) int {
+	text := strings.ToLower(in.Text)
+	score := 0
+	for _, phrase := range s.Phrases {
+		if strings.Contains(text, phrase) {
+			score += s.Points
+		}
+	}
+	return min(score, s.Max)
+}
+
+// NameSignal - RTL и невидимые символы Unicode в имени автора
+type NameSignal struct {
+	Points int
+}
+
+func (s NameSignal) Name() string { return "name" }
+
+func (s NameSignal) Score(in Input) int {
+	for _, name := range in.SenderNames {
+		for _, r := range name {
+			if isInvisibleRune(r) {
+				return s.Points
+			}
+		}
+	}
+	return 0
+}
+
+// isInvisibleRune проверяет, является ли символ невидимым или управляющим направлением текста
+func isInvisibleRune(r rune) bool {
+	switch {
+	case r >= 0x200B && r <= 0x200F, // пробелы нулевой ширины, метки LRM/RLM
+		r >= 0x202A && r <= 0x202E, // встраивание и переопределение направления
+		r >= 0x2060 && r <= 0x2064, // невидимые операторы
+		r >= 0x2066 && r <= 0x2069, // изоляция направления
+		r == 0xFEFF, r == 0x00AD, r == 0x115F, r == 0x1160, r == 0x3164:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,148 @@
+package textcases
+
+import (
+	"saxbot/textdist"
+	"strings"
+	"unicode"
+)
+
+// Насколько ответ на квиз похож на название песни
+type AnswerMatch int
+
+const (
+	AnswerWrong   AnswerMatch = iota // Не похоже
+	AnswerClose                      // Почти: пара лишних опечаток
+	AnswerCorrect                    // Правильно, с точностью до регистра, знаков препинания и опечаток
+)
+
+// Встроенные прозвища песен, дополняются таблицей song_aliases
+var defaultSongAliases = map[string][]string{
+	"Дайте мне Бензопилу":          {"Бензопила"},
+	"Пацанский хит для топ-чартов": {"Пацанский хит"},
+	"Казнить, Казнить, Казнить":    {"Казнить"},
+	"Расправа над Бабой-Ягой":      {"Баба-Яга", "Баба Яга"},
+	"Russian cyberpunk rave":       {"Cyberpunk rave", "Киберпанк рейв"},
+}
+
+// Транслитерация кириллицы в латиницу для сравнения ответов, написанных разными алфавитами
+var translit = strings.NewReplacer(
+	"а", "a", "б", "b", "в", "v", "г", "g", "д", "d", "е", "e", "ж", "zh", "з", "z", "и", "i",
+	"й", "y", "к", "k", "л", "l", "м", "m", "н", "n", "о", "o", "п", "p", "р", "r", "с", "s",
+	"т", "t", "у", "u", "ф", "f", "х", "h", "ц", "ts", "ч", "ch", "ш", "sh", "щ", "sch", "ъ", "",
+	"ы", "y", "ь", "", "э", "e", "ю", "yu", "я", "ya",
+)
+
+// DefaultSongAliases возвращает встроенные прозвища песни. Название сравнивается после нормализации,
+// потому что в цитатах, клипах и треклистах одна песня может быть записана по-разному
+func DefaultSongAliases(song string) []string {
+	key := NormalizeAnswer(song)
+	for title, aliases := range defaultSongAliases {
+		if NormalizeAnswer(title) == key {
+			return aliases
+		}
+	}
+	return nil
+}
+
+// NormalizeAnswer приводит ответ к виду для сравнения: нижний регистр, "ё" как "е",
+// без знаков препинания, слова через один пробел
+func NormalizeAnswer(text string) string {
+	var sb strings.Builder
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case r == 'ё':
+			sb.WriteRune('е')
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			sb.WriteRune(r)
+		default:
+			sb.WriteRune(' ')
+		}
+	}
+	return strings.Join(strings.Fields(sb.String()), " ")
+}
+
+// typoThreshold возвращает, сколько опечаток прощается в названии такой длины
+func typoThreshold(length int) int {
+	switch {
+	case length <= 4:
+		return 0
+	case length <= 8:
+		return 1
+	case length <= 14:
+		return 2
+	}
+	return 3
+}
+
+// MatchQuizAnswer сравнивает ответ с названием песни и ее прозвищами. Ответ и название сравниваются
+// после нормализации и транслитерации в латиницу, допустимое число опечаток зависит от длины названия
+func MatchQuizAnswer(answer, song string, aliases []string) AnswerMatch {
+	match, _ := matchAnswer(answer, song, aliases)
+	return match
+}
+
+// matchAnswer сравнивает ответ как MatchQuizAnswer и для правильного ответа возвращает число опечаток
+// в самом похожем из названия и прозвищ
+func matchAnswer(answer, song string, aliases []string) (AnswerMatch, int) {
+	normalized := NormalizeAnswer(answer)
+	if normalized == "" {
+		return AnswerWrong, 0
+	}
+	latin := translit.Replace(normalized)
+
+	result, best := AnswerWrong, 0
+	for _, candidate := range append([]string{song}, aliases...) {
+		target := NormalizeAnswer(candidate)
+		if target == "" {
+			continue
+		}
+		if target == normalized {
+			return AnswerCorrect, 0
+		}
+		targetLatin := translit.Replace(target)
+		length := len([]rune(targetLatin))
+		distance := textdist.Levenshtein(latin, targetLatin)
+		threshold := typoThreshold(length)
+		switch {
+		case distance <= threshold:
+			if result != AnswerCorrect || distance < best {
+				result, best = AnswerCorrect, distance
+			}
+		case result != AnswerCorrect && length > 4 && distance <= threshold+2 && distance*2 < length:
+			result = AnswerClose
+		}
+	}
+	return result, best
+}
+
+// Сколько слов можно пропустить в строчке для квиза "продолжи строчку"
+const lyricsMaxMissing = 8
+
+// SplitLyrics делит цитату для квиза "продолжи строчку": начало показывается, а пропущенные слова нужно угадать.
+// Если последняя строка цитаты короткая, пропускается она целиком, иначе вторая половина последней строки.
+// Второе значение - пропущенные слова, false - цитата слишком короткая
+func SplitLyrics(quote string) (string, string, bool) {
+	var lines []string
+	for _, line := range strings.Split(quote, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return "", "", false
+	}
+	prefix := lines[:len(lines)-1]
+	words := strings.Fields(lines[len(lines)-1])
+	if len(prefix) > 0 && len(words) >= 2 && len(words) <= lyricsMaxMissing {
+		return strings.Join(prefix, "\n"), strings.Join(words, " "), true
+	}
+	if len(words) < 4 {
+		return "", "", false
+	}
+	half := len(words) / 2
+	if len(words)-half > lyricsMaxMissing {
+		half = len(words) - lyricsMaxMissing
+	}
+	shown := append(append([]string{}, prefix...), strings.Join(words[:half], " "))
+	return strings.Join(shown, "\n"), strings.Join(words[half:], " "), true
+}
@@ -1,8 +1,14 @@
 package textcases
 
 import (
+	"fmt"
 	"log"
+	"maps"
+	"math/rand"
 	"saxbot/database"
+	"slices"
+	"strings"
+	"unicode"
 )
 
 var albums = map[int]string{
@@ -36,6 +42,118 @@ func GetAlbumTracklist(album int) map[int]string {
 	}
 }
 
+// IsTrackTitle проверяет, совпадает ли ответ с названием какого-нибудь трека из треклистов
+// так же, как ответ на квиз: без учета регистра, знаков препинания, "ё" и пары опечаток
+func IsTrackTitle(answer string) bool {
+	_, ok := FindTrackTitle(answer)
+	return ok
+}
+
+// sortedAlbums возвращает id альбомов по возрастанию, чтобы поиск по треклистам не зависел от порядка обхода карты
+func sortedAlbums() []int {
+	return slices.Sorted(maps.Keys(albums))
+}
+
+// sortedTracklist возвращает треклист альбома в порядке номеров треков
+func sortedTracklist(album int) []string {
+	tracklist := GetAlbumTracklist(album)
+	titles := make([]string, 0, len(tracklist))
+	for _, number := range slices.Sorted(maps.Keys(tracklist)) {
+		titles = append(titles, tracklist[number])
+	}
+	return titles
+}
+
+// FindTrackTitle возвращает название трека из треклистов, на которое похож ответ. Если ответ подходит
+// к нескольким трекам, берется трек с наименьшим числом опечаток, из равных - первый по альбомам и номерам
+func FindTrackTitle(answer string) (string, bool) {
+	found, best := "", 0
+	for _, album := range sortedAlbums() {
+		for _, title := range sortedTracklist(album) {
+			match, distance := matchAnswer(answer, title, DefaultSongAliases(title))
+			if match != AnswerCorrect {
+				continue
+			}
+			if found == "" || distance < best {
+				found, best = title, distance
+			}
+			if best == 0 {
+				return found, true
+			}
+		}
+	}
+	return found, found != ""
+}
+
+// FindTrackAlbum возвращает название альбома с треком. Синглы учитываются, только если трека нет ни на одном альбоме.
+// Если трек есть на нескольких альбомах, берется первый по id
+func FindTrackAlbum(title string) (string, bool) {
+	singles := ""
+	for _, album := range sortedAlbums() {
+		if !slices.Contains(sortedTracklist(album), title) {
+			continue
+		}
+		if album != 1 {
+			return albums[album], true
+		}
+		singles = albums[album]
+	}
+	return singles, singles != ""
+}
+
+// GetPollOptions возвращает четыре варианта ответа для квиза-опроса: правильное название и три других трека
+// из треклистов в случайном порядке. Второе значение - номер правильного варианта
+func GetPollOptions(song string) ([]string, int) {
+	seen := map[string]bool{NormalizeAnswer(song): true}
+	var others []string
+	for album := range albums {
+		for _, title := range GetAlbumTracklist(album) {
+			key := NormalizeAnswer(title)
+			if seen[key] {
+				continue
+			}
+			seen[key] = true
+			others = append(others, title)
+		}
+	}
+	rand.Shuffle(len(others), func(i, j int) { others[i], others[j] = others[j], others[i] })
+	if len(others) > 3 {
+		others = others[:3]
+	}
+	correct := rand.Intn(len(others) + 1)
+	options := append([]string{}, others[:correct]...)
+	options = append(options, song)
+	options = append(options, others[correct:]...)
+	return options, correct
+}
+
+// QuizHints возвращает подсказки к песне квиза по порядку: альбом, первая буква, число слов в названии.
+// Подсказки выдаются по номеру, поэтому их всегда три: если альбом не нашелся, вместо него - число букв
+func QuizHints(song string) []string {
+	hints := make([]string, 0, 3)
+	album, ok := FindTrackAlbum(song)
+	switch {
+	case !ok:
+		letters := 0
+		for _, r := range song {
+			if unicode.IsLetter(r) {
+				letters++
+			}
+		}
+		hints = append(hints, fmt.Sprintf("Подсказка: букв в названии - %d", letters))
+	case album == albums[1]:
+		hints = append(hints, "Подсказка: это сингл")
+	default:
+		hints = append(hints, fmt.Sprintf("Подсказка: песня с альбома \"%s\"", album))
+	}
+	first := "?"
+	if runes := []rune(strings.TrimSpace(song)); len(runes) > 0 {
+		first = strings.ToUpper(string(runes[0]))
+	}
+	hints = append(hints, fmt.Sprintf("Подсказка: название начинается на \"%s\"", first))
+	return append(hints, fmt.Sprintf("Подсказка: слов в названии - %d", len(strings.Fields(song))))
+}
+
 var chertovshinaTracklist = map[int]string{
 	1:  "Быличка",
 	2:  "Рейв на Могилке",
@@ -6,12 +6,16 @@ import (
 	"math/rand"
 	"saxbot/database"
 	"saxbot/environment"
+	"sort"
 	"strings"
 	"time"
 )
 
 var QuizAnnouncement = "Интерактив! Угадай песню по цитате! Кто первый даст правильный ответ, получит приз!"
 var QuizClipAnnouncement = "Интерактив! Угадай песню по кадру из клипа! Кто первый даст правильный ответ, получит приз!"
+var QuizPollQuestion = "Интерактив! Из какой песни эта строчка?"
+var QuizLyricsAnnouncement = "Интерактив! Продолжи строчку из песни! Кто первый допишет пропущенные слова, получит приз!"
+var QuizEmojiAnnouncement = "Интерактив! Угадай песню по эмодзи! Кто первый даст правильный ответ, получит приз!"
 
 func GetWarnCase(username string) string {
 	var warnCases = []string{
@@ -415,19 +419,9 @@ var clipScreensDirs = map[string]string{
 	"Всегда Готов": "gotov",
 }
 
-func GetRandomClip() map[string]string {
-	result := make(map[string]string)
-	keys := make([]string, 0, len(clipScreensDirs))
-	for k := range clipScreensDirs {
-		keys = append(keys, k)
-	}
-	if len(keys) == 0 {
-		return result
-	}
-	randomIdx := rand.Intn(len(keys))
-	key := keys[randomIdx]
-	result[key] = clipScreensDirs[key]
-	return result
+// GetClipScreensDirs возвращает папки с кадрами клипов: название песни -> папка в images/clips
+func GetClipScreensDirs() map[string]string {
+	return clipScreensDirs
 }
 
 func GetCongratulationsMessage(isAlone bool) string {
@@ -551,3 +545,39 @@ func getUserZodiac(repo *database.PostgresRepository, userID int64) (string, err
 func GetSerbMessage() string {
 	return "Трымай пыпыску"
 }
+
+// GetAdminStatsReport формирует отчет по модерации админов за days дней
+func GetAdminStatsReport(stats []database.AdminStats, names map[int64]string, days int, unanswered int) string {
+	sort.Slice(stats, func(i, j int) bool {
+		ti := stats[i].Warns + stats[i].Mutes + stats[i].Restricts + stats[i].Bans + stats[i].Kicks + stats[i].Reports
+		tj := stats[j].Warns + stats[j].Mutes + stats[j].Restricts + stats[j].Bans + stats[j].Kicks + stats[j].Reports
+		return ti > tj
+	})
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("Статистика модерации за %d дн.\n", days))
+	if len(stats) == 0 {
+		sb.WriteString("\nАдмины никого не наказывали и не отвечали на вызовы\n")
+	}
+	for _, s := range stats {
+		name := names[s.AdminID]
+		if name == "" {
+			name = fmt.Sprintf("id %d", s.AdminID)
+		}
+		sb.WriteString(fmt.Sprintf("\n%s\n", name))
+		sb.WriteString(fmt.Sprintf("Преды: %d, муты: %d, рестрикты: %d, баны: %d, кики: %d\n", s.Warns, s.Mutes, s.Restricts, s.Bans, s.Kicks))
+		if s.Mutes > 0 {
+			sb.WriteString(fmt.Sprintf("Средний мут: %.0f мин\n", s.AvgMuteMinutes()))
+		}
+		if s.Reverted > 0 {
+			sb.WriteString(fmt.Sprintf("Отменено потом: %d\n", s.Reverted))
+		}
+		if s.Reports > 0 {
+			sb.WriteString(fmt.Sprintf("Ответил на вызовов: %d, среднее время ответа: %s\n", s.Reports, s.AvgResponse().Round(time.Second)))
+		}
+	}
+	if unanswered > 0 {
+		sb.WriteString(fmt.Sprintf("\nВызовов админов без ответа: %d\n", unanswered))
+	}
+	return sb.String()
+}